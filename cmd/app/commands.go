@@ -0,0 +1,398 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/urfave/cli/v2"
+
+	"github.com/hollisgr/subservice/internal/actor"
+	"github.com/hollisgr/subservice/internal/app"
+	"github.com/hollisgr/subservice/internal/config"
+	"github.com/hollisgr/subservice/internal/dto"
+	"github.com/hollisgr/subservice/internal/interfaces"
+	"github.com/hollisgr/subservice/internal/migrate"
+	"github.com/hollisgr/subservice/internal/model"
+	"github.com/hollisgr/subservice/internal/services/rates"
+	"github.com/hollisgr/subservice/internal/services/subscriptions"
+)
+
+const (
+	formatJSON = "json"
+	formatCSV  = "csv"
+)
+
+// exportPageSize is how many subscriptions export loads at once.
+const exportPageSize = 100
+
+// csvColumns are the columns export writes and import reads, import ignores
+// id and version and needs the columns by name only.
+var csvColumns = []string{"id", "service_name", "price", "user_id", "start_date", "end_date", "billing_period", "currency", "version"}
+
+var (
+	ErrIncorrectFormat = errors.New("incorrect format, expected json or csv")
+	ErrIncorrectUser   = errors.New("incorrect user id")
+)
+
+func serve(c *cli.Context) error {
+	cfg := config.GetConfig()
+
+	if cfg.Migrate.OnStart {
+		err := app.Migrate(c.Context, cfg, migrate.CommandUp)
+		if err != nil {
+			return fmt.Errorf("migrate on start err: %w", err)
+		}
+	}
+
+	storage, closeStorage := app.SetupStorage(cfg)
+	defer closeStorage()
+
+	app.SetupLogger(cfg.Logger.LogLevel)
+
+	subServ := subscriptions.New(storage)
+
+	rateServ := rates.New(storage)
+
+	router := app.SetupRouter(subServ, rateServ)
+
+	server := app.SetupServer(cfg, router)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	app.StartPurge(ctx, cfg, subServ)
+
+	app.StartServer(server)
+
+	app.HandleQuit(server)
+	return nil
+}
+
+func runMigrate(command string) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		return app.Migrate(c.Context, config.GetConfig(), command)
+	}
+}
+
+// newSubService creates the subscription service on the configured storage,
+// the returned function releases the storage.
+func newSubService() (interfaces.Subscriptions, func()) {
+	cfg := config.GetConfig()
+	storage, closeStorage := app.SetupStorage(cfg)
+	app.SetupLogger(cfg.Logger.LogLevel)
+	return subscriptions.New(storage), closeStorage
+}
+
+func importSubscriptions(c *cli.Context) error {
+	in, err := openInput(c.String("file"))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	var list []dto.CreateSubRequest
+	switch c.String("format") {
+	case formatJSON:
+		err = json.NewDecoder(in).Decode(&list)
+	case formatCSV:
+		list, err = readCSV(in)
+	default:
+		err = ErrIncorrectFormat
+	}
+	if err != nil {
+		return fmt.Errorf("import read err: %w", err)
+	}
+
+	subServ, closeStorage := newSubService()
+	defer closeStorage()
+
+	ctx := actor.WithActor(c.Context, "cli import")
+	for i, data := range list {
+		// обязательные поля проверяет обработчик HTTP, здесь его нет
+		if data.ServiceName == "" || data.UserId == uuid.Nil {
+			return fmt.Errorf("import record %d: service_name and user_id are required, %d imported", i+1, i)
+		}
+		_, err = subServ.Create(ctx, data)
+		if err != nil {
+			return fmt.Errorf("import record %d: %w, %d imported", i+1, err, i)
+		}
+	}
+
+	log.Println("Imported subscriptions:", len(list))
+	return nil
+}
+
+// readCSV reads subscriptions from CSV with a header naming the columns.
+func readCSV(r io.Reader) ([]dto.CreateSubRequest, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		columns[name] = i
+	}
+	for _, name := range []string{"service_name", "price", "user_id", "start_date"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("column %s is missing", name)
+		}
+	}
+	value := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok {
+			return ""
+		}
+		return record[i]
+	}
+
+	list := make([]dto.CreateSubRequest, 0, len(records)-1)
+	for n, record := range records[1:] {
+		price, err := decimal.NewFromString(value(record, "price"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: price: %v", n+2, err)
+		}
+		userId, err := uuid.Parse(value(record, "user_id"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: user_id: %v", n+2, err)
+		}
+		list = append(list, dto.CreateSubRequest{
+			ServiceName:   value(record, "service_name"),
+			Price:         &price,
+			UserId:        userId,
+			StartDate:     value(record, "start_date"),
+			EndDate:       value(record, "end_date"),
+			BillingPeriod: value(record, "billing_period"),
+			Currency:      value(record, "currency"),
+		})
+	}
+	return list, nil
+}
+
+func exportSubscriptions(c *cli.Context) error {
+	format := c.String("format")
+	if format != formatJSON && format != formatCSV {
+		return ErrIncorrectFormat
+	}
+	userId, err := parseUser(c.String("user"))
+	if err != nil {
+		return err
+	}
+
+	subServ, closeStorage := newSubService()
+	defer closeStorage()
+
+	request := dto.LoadListRequest{
+		Limit:       exportPageSize,
+		UserId:      userId,
+		ServiceName: c.String("service"),
+	}
+	list := []dto.LoadSubResponce{}
+	for {
+		page, err := subServ.LoadList(c.Context, request)
+		if err != nil {
+			return fmt.Errorf("export load err: %w", err)
+		}
+		list = append(list, page.Items...)
+		if page.NextCursor == "" {
+			break
+		}
+		request.Cursor = page.NextCursor
+	}
+
+	out, err := openOutput(c.String("file"))
+	if err != nil {
+		return err
+	}
+
+	if format == formatCSV {
+		err = writeCSV(out, list)
+	} else {
+		err = writeJSON(out, list)
+	}
+	closeErr := out.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("export write err: %w", err)
+	}
+
+	log.Println("Exported subscriptions:", len(list))
+	return nil
+}
+
+func writeCSV(w io.Writer, list []dto.LoadSubResponce) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write(csvColumns)
+	if err != nil {
+		return err
+	}
+	for _, sub := range list {
+		err = writer.Write([]string{
+			strconv.Itoa(sub.Id),
+			sub.ServiceName,
+			sub.Price.StringFixed(model.MinorUnits),
+			sub.UserId.String(),
+			sub.StartDate,
+			sub.EndDate,
+			sub.BillingPeriod,
+			sub.Currency,
+			strconv.Itoa(sub.Version),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func cost(c *cli.Context) error {
+	userId, err := parseUser(c.String("user"))
+	if err != nil {
+		return err
+	}
+	request := dto.CostRequest{
+		ServiceName:    c.String("service"),
+		UserId:         userId,
+		StartDate:      c.String("from"),
+		EndDate:        c.String("to"),
+		TargetCurrency: c.String("currency"),
+		Prorate:        c.Bool("prorate"),
+	}
+
+	subServ, closeStorage := newSubService()
+	defer closeStorage()
+
+	if c.Bool("monthly") {
+		res, err := subServ.CostMonthly(c.Context, request)
+		if err != nil {
+			return fmt.Errorf("cost err: %w", err)
+		}
+		return writeJSON(os.Stdout, res)
+	}
+
+	res, err := subServ.Cost(c.Context, request)
+	if err != nil {
+		return fmt.Errorf("cost err: %w", err)
+	}
+	return writeJSON(os.Stdout, res)
+}
+
+// seedServices are the demo services with their monthly prices.
+var seedServices = []struct {
+	name  string
+	price string
+}{
+	{"Yandex Plus", "399"},
+	{"Kinopoisk", "299"},
+	{"Okko", "399"},
+	{"Kion", "249.9"},
+	{"Ivi", "399"},
+	{"Wink", "349"},
+	{"VK Music", "249"},
+	{"Litres", "399"},
+}
+
+var seedPeriods = []string{model.PeriodMonthly, model.PeriodMonthly, model.PeriodMonthly, model.PeriodQuarterly, model.PeriodAnnual, model.PeriodWeekly}
+
+func seed(c *cli.Context) error {
+	count, users := c.Int("count"), c.Int("users")
+	if count < 0 || users < 1 {
+		return errors.New("count must not be negative and users must be positive")
+	}
+	random := rand.New(rand.NewSource(c.Int64("seed")))
+
+	userIds := make([]uuid.UUID, users)
+	for i := range userIds {
+		id, err := uuid.NewRandomFromReader(random)
+		if err != nil {
+			return err
+		}
+		userIds[i] = id
+	}
+
+	subServ, closeStorage := newSubService()
+	defer closeStorage()
+
+	ctx := actor.WithActor(c.Context, "cli seed")
+	now := time.Now().UTC()
+	for i := 0; i < count; i++ {
+		service := seedServices[random.Intn(len(seedServices))]
+		start := now.AddDate(0, -random.Intn(24), -random.Intn(28))
+		price := decimal.RequireFromString(service.price)
+		data := dto.CreateSubRequest{
+			ServiceName:   service.name,
+			Price:         &price,
+			UserId:        userIds[random.Intn(users)],
+			StartDate:     start.Format("02-01-2006"),
+			BillingPeriod: seedPeriods[random.Intn(len(seedPeriods))],
+		}
+		// примерно треть подписок уже закончилась
+		if random.Intn(3) == 0 {
+			data.EndDate = start.AddDate(0, 1+random.Intn(12), -1).Format("02-01-2006")
+		}
+
+		_, err := subServ.Create(ctx, data)
+		if err != nil {
+			return fmt.Errorf("seed subscription %d err: %w", i+1, err)
+		}
+	}
+
+	log.Println("Seeded subscriptions:", count)
+	return nil
+}
+
+func parseUser(str string) (uuid.UUID, error) {
+	if str == "" {
+		return uuid.Nil, nil
+	}
+	id, err := uuid.Parse(str)
+	if err != nil {
+		return uuid.Nil, ErrIncorrectUser
+	}
+	return id, nil
+}
+
+func openInput(name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(name)
+}
+
+func openOutput(name string) (io.WriteCloser, error) {
+	if name == "-" {
+		return nopWriteCloser{os.Stdout}, nil
+	}
+	return os.Create(name)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
@@ -1,29 +1,98 @@
 package main
 
 import (
-	"main/internal/app"
-	"main/internal/config"
-	"main/internal/db"
-	"main/internal/services/subscriptions"
-)
-
-func main() {
-	cfg := config.GetConfig()
-
-	pgxPool := app.ConnectToDB(cfg)
-	defer pgxPool.Close()
-
-	app.SetupLogger(cfg.Logger.LogLevel)
+	"log"
+	"os"
 
-	storage := db.New(pgxPool)
+	"github.com/urfave/cli/v2"
 
-	subServ := subscriptions.New(storage)
-
-	router := app.SetupRouter(subServ)
-
-	server := app.SetupServer(cfg, router)
+	"github.com/hollisgr/subservice/internal/migrate"
+)
 
-	app.StartServer(server)
+func main() {
+	application := &cli.App{
+		Name:  "main",
+		Usage: "subscription service and its admin tasks",
+		// без подкоманды запускается сервер, как и раньше
+		Action:          serve,
+		HideHelpCommand: true,
+		Commands: []*cli.Command{
+			{
+				Name:   "serve",
+				Usage:  "start the HTTP server",
+				Action: serve,
+			},
+			{
+				Name:            "migrate",
+				Usage:           "apply or roll back the embedded database migrations",
+				HideHelpCommand: true,
+				Subcommands: []*cli.Command{
+					{
+						Name:   migrate.CommandUp,
+						Usage:  "apply all pending migrations",
+						Action: runMigrate(migrate.CommandUp),
+					},
+					{
+						Name:   migrate.CommandDown,
+						Usage:  "roll back the last migration",
+						Action: runMigrate(migrate.CommandDown),
+					},
+					{
+						Name:   migrate.CommandStatus,
+						Usage:  "print the state of every migration",
+						Action: runMigrate(migrate.CommandStatus),
+					},
+				},
+			},
+			{
+				Name:  "import",
+				Usage: "create subscriptions from a JSON or CSV file",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "file", Aliases: []string{"f"}, Value: "-", Usage: "file to read, - for stdin"},
+					&cli.StringFlag{Name: "format", Value: formatJSON, Usage: "json or csv"},
+				},
+				Action: importSubscriptions,
+			},
+			{
+				Name:  "export",
+				Usage: "write subscriptions to a JSON or CSV file",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "file", Aliases: []string{"f"}, Value: "-", Usage: "file to write, - for stdout"},
+					&cli.StringFlag{Name: "format", Value: formatJSON, Usage: "json or csv"},
+					&cli.StringFlag{Name: "user", Usage: "export subscriptions of the user id only"},
+					&cli.StringFlag{Name: "service", Usage: "export subscriptions of the service only"},
+				},
+				Action: exportSubscriptions,
+			},
+			{
+				Name:  "cost",
+				Usage: "print the cost of subscriptions in a period",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "from", Required: true, Usage: "period start, DD-MM-YYYY, YYYY-MM-DD, MM-YYYY or YYYY-MM"},
+					&cli.StringFlag{Name: "to", Required: true, Usage: "inclusive period end in the same formats"},
+					&cli.StringFlag{Name: "user", Usage: "user id filter"},
+					&cli.StringFlag{Name: "service", Usage: "service name filter"},
+					&cli.StringFlag{Name: "currency", Usage: "currency to convert the cost to, RUB by default"},
+					&cli.BoolFlag{Name: "prorate", Usage: "charge cut billing periods for their active days only"},
+					&cli.BoolFlag{Name: "monthly", Usage: "break the cost down by month"},
+				},
+				Action: cost,
+			},
+			{
+				Name:  "seed",
+				Usage: "create random demo subscriptions",
+				Flags: []cli.Flag{
+					&cli.IntFlag{Name: "count", Value: 20, Usage: "number of subscriptions"},
+					&cli.IntFlag{Name: "users", Value: 5, Usage: "number of users owning them"},
+					&cli.Int64Flag{Name: "seed", Value: 1, Usage: "random seed, the same seed makes the same data"},
+				},
+				Action: seed,
+			},
+		},
+	}
 
-	app.HandleQuit(server)
+	err := application.Run(os.Args)
+	if err != nil {
+		log.Fatalln(err)
+	}
 }
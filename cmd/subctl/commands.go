@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/urfave/cli/v2"
+
+	"github.com/hollisgr/subservice/pkg/client"
+)
+
+var (
+	ErrIncorrectId  = errors.New("expected a subscription id argument")
+	ErrNothingToSet = errors.New("nothing to update, pass the fields to change")
+)
+
+func create(c *cli.Context) error {
+	api, output, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	price, err := decimal.NewFromString(c.String("price"))
+	if err != nil {
+		return fmt.Errorf("incorrect price: %w", err)
+	}
+	userId, err := uuid.Parse(c.String("user"))
+	if err != nil {
+		return fmt.Errorf("incorrect user id: %w", err)
+	}
+
+	id, err := api.Create(c.Context, client.CreateSubRequest{
+		ServiceName:   c.String("service"),
+		Price:         price,
+		UserId:        userId,
+		StartDate:     c.String("start"),
+		EndDate:       c.String("end"),
+		BillingPeriod: c.String("period"),
+		Currency:      c.String("currency"),
+	})
+	if err != nil {
+		return err
+	}
+	return show(c.Context, api, output, id)
+}
+
+func list(c *cli.Context) error {
+	api, output, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	req := client.LoadListRequest{
+		Limit:         c.Int("limit"),
+		Offset:        c.Int("offset"),
+		ServiceName:   c.String("service"),
+		ServicePrefix: c.String("prefix"),
+		ActiveMonth:   c.String("active-month"),
+		Sort:          c.String("sort"),
+		Order:         c.String("order"),
+		Cursor:        c.String("cursor"),
+	}
+	if c.String("user") != "" {
+		req.UserId, err = uuid.Parse(c.String("user"))
+		if err != nil {
+			return fmt.Errorf("incorrect user id: %w", err)
+		}
+	}
+	req.PriceMin, err = optionalPrice(c.String("price-min"))
+	if err != nil {
+		return err
+	}
+	req.PriceMax, err = optionalPrice(c.String("price-max"))
+	if err != nil {
+		return err
+	}
+
+	items := []client.LoadSubResponce{}
+	var page client.LoadListResponce
+	for {
+		page, err = api.LoadList(c.Context, req)
+		if err != nil {
+			return err
+		}
+		items = append(items, page.Items...)
+		if !c.Bool("all") || page.NextCursor == "" {
+			break
+		}
+		req.Cursor = page.NextCursor
+	}
+
+	if output == outputJSON {
+		// список печатается массивом даже из одного элемента
+		err = writeJSON(os.Stdout, items)
+	} else {
+		err = writeSubscriptions(os.Stdout, output, items...)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "%d of %d subscriptions\n", len(items), page.Total)
+	if !c.Bool("all") && page.NextCursor != "" {
+		fmt.Fprintf(os.Stderr, "next page: --cursor %s\n", page.NextCursor)
+	}
+	return nil
+}
+
+func get(c *cli.Context) error {
+	api, output, err := newClient(c)
+	if err != nil {
+		return err
+	}
+	id, err := argId(c)
+	if err != nil {
+		return err
+	}
+	return show(c.Context, api, output, id)
+}
+
+func update(c *cli.Context) error {
+	api, output, err := newClient(c)
+	if err != nil {
+		return err
+	}
+	id, err := argId(c)
+	if err != nil {
+		return err
+	}
+
+	// в патч попадают только переданные поля
+	var patch client.PatchSubRequest
+	changed := false
+	for flag, field := range map[string]**string{
+		"service":  &patch.ServiceName,
+		"start":    &patch.StartDate,
+		"period":   &patch.BillingPeriod,
+		"currency": &patch.Currency,
+	} {
+		if c.IsSet(flag) {
+			value := c.String(flag)
+			*field = &value
+			changed = true
+		}
+	}
+	if c.IsSet("price") {
+		price, err := decimal.NewFromString(c.String("price"))
+		if err != nil {
+			return fmt.Errorf("incorrect price: %w", err)
+		}
+		patch.Price = &price
+		changed = true
+	}
+	if c.IsSet("user") {
+		userId, err := uuid.Parse(c.String("user"))
+		if err != nil {
+			return fmt.Errorf("incorrect user id: %w", err)
+		}
+		patch.UserId = &userId
+		changed = true
+	}
+	if c.IsSet("end") {
+		patch.EndDate = client.NullableString{Set: true, Value: c.String("end")}
+		changed = true
+	}
+	if c.Bool("no-end") {
+		patch.EndDate = client.NullableString{Set: true, Null: true}
+		changed = true
+	}
+	if !changed {
+		return ErrNothingToSet
+	}
+
+	version, err := currentVersion(c, api, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = api.Patch(c.Context, id, version, patch)
+	if err != nil {
+		return err
+	}
+	return show(c.Context, api, output, id)
+}
+
+func remove(c *cli.Context) error {
+	api, _, err := newClient(c)
+	if err != nil {
+		return err
+	}
+	id, err := argId(c)
+	if err != nil {
+		return err
+	}
+
+	version, err := currentVersion(c, api, id)
+	if err != nil {
+		return err
+	}
+
+	err = api.Delete(c.Context, id, version)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "subscription %d deleted\n", id)
+	return nil
+}
+
+func cost(c *cli.Context) error {
+	api, output, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	req := client.CostRequest{
+		ServiceName:    c.String("service"),
+		StartDate:      c.String("from"),
+		EndDate:        c.String("to"),
+		TargetCurrency: c.String("currency"),
+		Prorate:        c.Bool("prorate"),
+	}
+	if c.String("user") != "" {
+		req.UserId, err = uuid.Parse(c.String("user"))
+		if err != nil {
+			return fmt.Errorf("incorrect user id: %w", err)
+		}
+	}
+
+	if c.Bool("monthly") {
+		res, err := api.CostMonthly(c.Context, req)
+		if err != nil {
+			return err
+		}
+		return writeCostMonthly(os.Stdout, output, res)
+	}
+
+	res, err := api.Cost(c.Context, req)
+	if err != nil {
+		return err
+	}
+	return writeCost(os.Stdout, output, res)
+}
+
+// show loads the subscription and prints it.
+func show(ctx context.Context, api *client.Client, output string, id int) error {
+	sub, err := api.Load(ctx, id)
+	if err != nil {
+		return err
+	}
+	return writeSubscriptions(os.Stdout, output, sub)
+}
+
+// currentVersion returns the --version flag or, without it, the version
+// the subscription has now.
+func currentVersion(c *cli.Context, api *client.Client, id int) (int, error) {
+	if c.IsSet("version") {
+		return c.Int("version"), nil
+	}
+	sub, err := api.Load(c.Context, id)
+	if err != nil {
+		return 0, err
+	}
+	return sub.Version, nil
+}
+
+func optionalPrice(str string) (*decimal.Decimal, error) {
+	if str == "" {
+		return nil, nil
+	}
+	price, err := decimal.NewFromString(str)
+	if err != nil {
+		return nil, fmt.Errorf("incorrect price: %w", err)
+	}
+	return &price, nil
+}
+
+func argId(c *cli.Context) (int, error) {
+	id, err := strconv.Atoi(c.Args().First())
+	if err != nil || id <= 0 || c.NArg() != 1 {
+		return 0, ErrIncorrectId
+	}
+	return id, nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ilyakaznacheev/cleanenv"
+	"github.com/urfave/cli/v2"
+
+	"github.com/hollisgr/subservice/pkg/client"
+)
+
+// fileConfig is the YAML config file, flags and environment variables
+// override its values.
+type fileConfig struct {
+	Server string `yaml:"server"`
+	Actor  string `yaml:"actor"`
+	Token  string `yaml:"token"`
+	Output string `yaml:"output"`
+}
+
+// defaultConfigPath returns ~/.config/subctl/config.yaml.
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "subctl", "config.yaml")
+}
+
+// loadConfig reads the config file, a missing file at the default path
+// is not an error.
+func loadConfig(c *cli.Context) (fileConfig, error) {
+	var cfg fileConfig
+	path := c.String("config")
+	if path == "" {
+		return cfg, nil
+	}
+
+	_, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) && !c.IsSet("config") {
+		return cfg, nil
+	}
+
+	err = cleanenv.ReadConfig(path, &cfg)
+	if err != nil {
+		return cfg, fmt.Errorf("read config %s err: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// setting returns the flag value if it is set by a flag or its environment
+// variable, then the config file value, then the flag default.
+func setting(c *cli.Context, name string, fromFile string) string {
+	if c.IsSet(name) || fromFile == "" {
+		return c.String(name)
+	}
+	return fromFile
+}
+
+// newClient creates the API client and output format from the global flags and the config file.
+func newClient(c *cli.Context) (*client.Client, string, error) {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return nil, "", err
+	}
+
+	output := setting(c, "output", cfg.Output)
+	if output != outputTable && output != outputJSON && output != outputCSV {
+		return nil, "", fmt.Errorf("incorrect output %q, expected table, json or csv", output)
+	}
+
+	api := client.New(setting(c, "server", cfg.Server),
+		client.WithActor(setting(c, "actor", cfg.Actor)),
+		client.WithToken(setting(c, "token", cfg.Token)),
+		client.WithHTTPClient(&http.Client{Timeout: c.Duration("timeout")}),
+	)
+	return api, output, nil
+}
+
+var globalFlags = []cli.Flag{
+	&cli.StringFlag{Name: "server", Aliases: []string{"s"}, Value: "http://127.0.0.1:8888", EnvVars: []string{"SUBCTL_SERVER"}, Usage: "service URL"},
+	&cli.StringFlag{Name: "actor", EnvVars: []string{"SUBCTL_ACTOR"}, Usage: "name recorded in the history of changes, sent as X-Actor"},
+	&cli.StringFlag{Name: "token", EnvVars: []string{"SUBCTL_TOKEN"}, Usage: "bearer token sent in the Authorization header"},
+	&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Value: outputTable, EnvVars: []string{"SUBCTL_OUTPUT"}, Usage: "table, json or csv"},
+	&cli.StringFlag{Name: "config", Value: defaultConfigPath(), EnvVars: []string{"SUBCTL_CONFIG"}, Usage: "YAML file with server, actor, token and output"},
+	&cli.DurationFlag{Name: "timeout", Value: 30 * time.Second, Usage: "request timeout"},
+}
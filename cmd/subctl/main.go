@@ -0,0 +1,105 @@
+// Command subctl manages subscriptions through the REST API of the service.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+)
+
+func main() {
+	application := &cli.App{
+		Name:            "subctl",
+		Usage:           "manage subscriptions through the subscription service API",
+		Flags:           globalFlags,
+		HideHelpCommand: true,
+		Commands: []*cli.Command{
+			{
+				Name:  "create",
+				Usage: "create a subscription",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "service", Required: true, Usage: "service name"},
+					&cli.StringFlag{Name: "price", Required: true, Usage: "price per billing period, e.g. 399.99"},
+					&cli.StringFlag{Name: "user", Required: true, Usage: "user id"},
+					&cli.StringFlag{Name: "start", Required: true, Usage: "start date, DD-MM-YYYY, YYYY-MM-DD, MM-YYYY or YYYY-MM"},
+					&cli.StringFlag{Name: "end", Usage: "inclusive end date in the same formats"},
+					&cli.StringFlag{Name: "period", Usage: "weekly, monthly, quarterly or annual"},
+					&cli.StringFlag{Name: "currency", Usage: "price currency, RUB by default"},
+				},
+				Action: create,
+			},
+			{
+				Name:  "list",
+				Usage: "list subscriptions",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "user", Usage: "user id filter"},
+					&cli.StringFlag{Name: "service", Usage: "exact service name filter"},
+					&cli.StringFlag{Name: "prefix", Usage: "case-insensitive service name prefix filter"},
+					&cli.StringFlag{Name: "price-min", Usage: "minimal price"},
+					&cli.StringFlag{Name: "price-max", Usage: "maximal price"},
+					&cli.StringFlag{Name: "active-month", Usage: "month the subscriptions are active in, MM-YYYY"},
+					&cli.StringFlag{Name: "sort", Usage: "id, price, start_date, end_date or service_name"},
+					&cli.StringFlag{Name: "order", Usage: "asc or desc"},
+					&cli.IntFlag{Name: "limit", Value: 50, Usage: "page size"},
+					&cli.IntFlag{Name: "offset", Usage: "rows to skip"},
+					&cli.StringFlag{Name: "cursor", Usage: "next page cursor printed by the previous call"},
+					&cli.BoolFlag{Name: "all", Usage: "load all pages"},
+				},
+				Action: list,
+			},
+			{
+				Name:      "get",
+				Usage:     "show a subscription",
+				ArgsUsage: "ID",
+				Action:    get,
+			},
+			{
+				Name:      "update",
+				Usage:     "change the given fields of a subscription",
+				ArgsUsage: "ID",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "service", Usage: "service name"},
+					&cli.StringFlag{Name: "price", Usage: "price per billing period"},
+					&cli.StringFlag{Name: "user", Usage: "user id"},
+					&cli.StringFlag{Name: "start", Usage: "start date"},
+					&cli.StringFlag{Name: "end", Usage: "inclusive end date"},
+					&cli.BoolFlag{Name: "no-end", Usage: "make the subscription open-ended"},
+					&cli.StringFlag{Name: "period", Usage: "weekly, monthly, quarterly or annual"},
+					&cli.StringFlag{Name: "currency", Usage: "price currency"},
+					&cli.IntFlag{Name: "version", Usage: "expected version, the current one by default"},
+				},
+				Action: update,
+			},
+			{
+				Name:      "delete",
+				Usage:     "delete a subscription",
+				ArgsUsage: "ID",
+				Flags: []cli.Flag{
+					&cli.IntFlag{Name: "version", Usage: "expected version, the current one by default"},
+				},
+				Action: remove,
+			},
+			{
+				Name:  "cost",
+				Usage: "compute the cost of subscriptions in a period",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "from", Required: true, Usage: "period start"},
+					&cli.StringFlag{Name: "to", Required: true, Usage: "inclusive period end"},
+					&cli.StringFlag{Name: "user", Usage: "user id filter"},
+					&cli.StringFlag{Name: "service", Usage: "service name filter"},
+					&cli.StringFlag{Name: "currency", Usage: "currency to convert the cost to"},
+					&cli.BoolFlag{Name: "prorate", Usage: "charge cut billing periods for their active days only"},
+					&cli.BoolFlag{Name: "monthly", Usage: "break the cost down by month"},
+				},
+				Action: cost,
+			},
+		},
+	}
+
+	err := application.Run(os.Args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "subctl:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hollisgr/subservice/pkg/client"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputCSV   = "csv"
+)
+
+var subscriptionHeader = []string{"id", "service_name", "price", "current_price", "currency", "billing_period", "user_id", "start_date", "end_date", "version"}
+
+func subscriptionRow(sub client.LoadSubResponce) []string {
+	current := ""
+	if sub.CurrentPrice != nil {
+		current = sub.CurrentPrice.StringFixed(2)
+	}
+	return []string{
+		strconv.Itoa(sub.Id),
+		sub.ServiceName,
+		sub.Price.StringFixed(2),
+		current,
+		sub.Currency,
+		sub.BillingPeriod,
+		sub.UserId.String(),
+		sub.StartDate,
+		sub.EndDate,
+		strconv.Itoa(sub.Version),
+	}
+}
+
+// writeSubscriptions prints the subscriptions in the output format,
+// a single subscription is printed as a JSON object rather than an array.
+func writeSubscriptions(w io.Writer, output string, subs ...client.LoadSubResponce) error {
+	if output == outputJSON {
+		if len(subs) == 1 {
+			return writeJSON(w, subs[0])
+		}
+		return writeJSON(w, subs)
+	}
+
+	rows := make([][]string, 0, len(subs))
+	for _, sub := range subs {
+		rows = append(rows, subscriptionRow(sub))
+	}
+	return writeRows(w, output, subscriptionHeader, rows)
+}
+
+var costHeader = []string{"service_name", "user_id", "cost", "months_count", "charges_count"}
+
+// writeCost prints the cost by service and user followed by the total.
+func writeCost(w io.Writer, output string, cost client.CostResponce) error {
+	if output == outputJSON {
+		return writeJSON(w, cost)
+	}
+
+	rows := make([][]string, 0, len(cost.Groups)+1)
+	for _, g := range cost.Groups {
+		rows = append(rows, []string{g.ServiceName, g.UserId.String(), g.Cost.StringFixed(2),
+			strconv.Itoa(g.MonthsCount), strconv.Itoa(g.ChargesCount)})
+	}
+	rows = append(rows, []string{"TOTAL " + cost.Currency, "", cost.Cost.StringFixed(2),
+		strconv.Itoa(cost.MonthsCount), strconv.Itoa(cost.ChargesCount)})
+	return writeRows(w, output, costHeader, rows)
+}
+
+var costMonthlyHeader = []string{"month", "id", "service_name", "user_id", "charges_count", "cost"}
+
+// writeCostMonthly prints every month with its subscriptions, a month row
+// holds the month total.
+func writeCostMonthly(w io.Writer, output string, cost client.CostMonthlyResponce) error {
+	if output == outputJSON {
+		return writeJSON(w, cost)
+	}
+
+	var rows [][]string
+	for _, m := range cost.Months {
+		rows = append(rows, []string{m.Month, "", "", "", "", m.Cost.StringFixed(2)})
+		for _, s := range m.Subscriptions {
+			rows = append(rows, []string{m.Month, strconv.Itoa(s.Id), s.ServiceName, s.UserId.String(),
+				strconv.Itoa(s.ChargesCount), s.Cost.StringFixed(2)})
+		}
+	}
+	rows = append(rows, []string{"TOTAL " + cost.Currency, "", "", "", "", cost.Cost.StringFixed(2)})
+	return writeRows(w, output, costMonthlyHeader, rows)
+}
+
+func writeRows(w io.Writer, output string, header []string, rows [][]string) error {
+	if output == outputCSV {
+		writer := csv.NewWriter(w)
+		err := writer.Write(header)
+		if err != nil {
+			return err
+		}
+		err = writer.WriteAll(rows)
+		if err != nil {
+			return err
+		}
+		return writer.Error()
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(header, "\t")))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -15,9 +15,141 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/exchange-rate": {
+            "get": {
+                "description": "Returns the exchange rates to RUB ordered by currency and month, optionally filtered by currency.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Exchange rate"
+                ],
+                "summary": "Read exchange rates",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "USD",
+                        "description": "Currency code",
+                        "name": "currency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.ExchangeRateResponce"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Sets the amount of RUB for one unit of the currency from the given month on, an existing rate for the month is replaced.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Exchange rate"
+                ],
+                "summary": "Set exchange rate",
+                "parameters": [
+                    {
+                        "description": "Exchange rate data",
+                        "name": "rate",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ExchangeRateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateSubResponce"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/exchange-rate/csv": {
+            "post": {
+                "description": "Stores the rates of a currency,month,rate CSV with an optional header row. The CSV is the request body or the \"file\" field of a multipart form. Nothing is stored if any row is incorrect.",
+                "consumes": [
+                    "text/csv",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Exchange rate"
+                ],
+                "summary": "Import exchange rates from CSV",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "CSV file",
+                        "name": "file",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ImportRatesResponce"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    }
+                }
+            }
+        },
         "/subscription": {
             "get": {
-                "description": "Returns a list of subscription objects",
+                "description": "Returns a filtered and sorted page of subscription objects, paginated by offset or by the next_cursor of the previous page, with the total count of matching subscriptions. An empty page is returned as an empty items array",
                 "produces": [
                     "application/json"
                 ],
@@ -28,10 +160,9 @@ const docTemplate = `{
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "offset",
+                        "description": "offset, can't be combined with cursor",
                         "name": "offset",
-                        "in": "query",
-                        "required": true
+                        "in": "query"
                     },
                     {
                         "type": "string",
@@ -39,34 +170,96 @@ const docTemplate = `{
                         "name": "limit",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "filter by user ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "filter by exact service name",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "filter by case-insensitive service name prefix",
+                        "name": "service_prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "minimal price",
+                        "name": "price_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "maximal price",
+                        "name": "price_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "subscriptions active in month, MM-YYYY",
+                        "name": "active_month",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "price",
+                            "start_date",
+                            "end_date",
+                            "service_name"
+                        ],
+                        "type": "string",
+                        "description": "sort field, service_name is sorted case-insensitively",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "sort order",
+                        "name": "order",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/dto.LoadSubResponce"
+                            "$ref": "#/definitions/dto.LoadListResponce"
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "total number of matching subscriptions"
                             }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/handler.ErrorBadRequest"
-                        }
-                    },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/handler.ErrorNotFound"
+                            "$ref": "#/definitions/handler.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handler.ErrorInternalError"
+                            "$ref": "#/definitions/handler.Problem"
                         }
                     }
                 }
@@ -104,19 +297,21 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/handler.ErrorBadRequest"
+                            "$ref": "#/definitions/handler.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handler.ErrorInternalError"
+                            "$ref": "#/definitions/handler.Problem"
                         }
                     }
                 }
-            },
-            "patch": {
-                "description": "Returns an ID updated subscription.",
+            }
+        },
+        "/subscription/cost": {
+            "post": {
+                "description": "Returns a total cost of billing charges falling into the period, optionally filtered by user ID and service name, grouped by service and user with a per-subscription breakdown, converted to the target currency",
                 "consumes": [
                     "application/json"
                 ],
@@ -126,15 +321,15 @@ const docTemplate = `{
                 "tags": [
                     "Subscription"
                 ],
-                "summary": "Update subscription by ID",
+                "summary": "Cost subscription",
                 "parameters": [
                     {
-                        "description": "Subscription update data",
+                        "description": "Subscription cost filters",
                         "name": "subscription",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.UpdateSubRequest"
+                            "$ref": "#/definitions/dto.CostRequest"
                         }
                     }
                 ],
@@ -142,33 +337,33 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.UpdateSubResponce"
+                            "$ref": "#/definitions/dto.CostResponce"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/handler.ErrorBadRequest"
+                            "$ref": "#/definitions/handler.Problem"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "422": {
+                        "description": "Unprocessable Entity",
                         "schema": {
-                            "$ref": "#/definitions/handler.ErrorNotFound"
+                            "$ref": "#/definitions/handler.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handler.ErrorInternalError"
+                            "$ref": "#/definitions/handler.Problem"
                         }
                     }
                 }
             }
         },
-        "/subscription/cost": {
+        "/subscription/cost/monthly": {
             "post": {
-                "description": "Returns a cost of subscriptions by user ID, date and service name",
+                "description": "Returns a month-by-month cost of billing charges for the period, optionally filtered by user ID and service name, with the contributing subscriptions of each month, converted to the target currency",
                 "consumes": [
                     "application/json"
                 ],
@@ -178,10 +373,10 @@ const docTemplate = `{
                 "tags": [
                     "Subscription"
                 ],
-                "summary": "Cost subscription",
+                "summary": "Monthly cost of subscriptions",
                 "parameters": [
                     {
-                        "description": "Subscription update data",
+                        "description": "Subscription cost filters",
                         "name": "subscription",
                         "in": "body",
                         "required": true,
@@ -194,25 +389,25 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.CostResponce"
+                            "$ref": "#/definitions/dto.CostMonthlyResponce"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/handler.ErrorBadRequest"
+                            "$ref": "#/definitions/handler.Problem"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "422": {
+                        "description": "Unprocessable Entity",
                         "schema": {
-                            "$ref": "#/definitions/handler.ErrorNotFound"
+                            "$ref": "#/definitions/handler.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handler.ErrorInternalError"
+                            "$ref": "#/definitions/handler.Problem"
                         }
                     }
                 }
@@ -242,30 +437,124 @@ const docTemplate = `{
                         "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/dto.LoadSubResponce"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "subscription version, pass it in If-Match to update or delete"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replaces every field of the subscription, a missing end_date makes it open-ended.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscription"
+                ],
+                "summary": "Replace subscription by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the subscription, * to skip the check",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Subscription update data",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateSubRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateSubResponce"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "new subscription version"
+                            }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/handler.ErrorBadRequest"
+                            "$ref": "#/definitions/handler.Problem"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/handler.ErrorNotFound"
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handler.ErrorInternalError"
+                            "$ref": "#/definitions/handler.Problem"
                         }
                     }
                 }
             },
             "delete": {
-                "description": "Returns an ID deleted subscription.",
+                "description": "Soft-deletes the subscription, it can be restored until the retention period purges it.",
                 "produces": [
                     "application/json"
                 ],
@@ -280,6 +569,13 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the subscription, * to skip the check",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -292,53 +588,555 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/handler.ErrorBadRequest"
+                            "$ref": "#/definitions/handler.Problem"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/handler.ErrorNotFound"
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handler.ErrorInternalError"
+                            "$ref": "#/definitions/handler.Problem"
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "dto.CostRequest": {
-            "type": "object",
-            "properties": {
-                "end_date": {
-                    "type": "string",
-                    "example": "02-2025"
-                },
-                "service_name": {
-                    "type": "string",
-                    "example": "Yandex Plus"
-                },
-                "start_date": {
-                    "type": "string",
-                    "example": "01-2025"
-                },
-                "user_id": {
-                    "type": "string",
-                    "example": "dceb1963-e152-47ff-a562-81a360627309"
-                }
-            }
+            },
+            "patch": {
+                "description": "Applies a JSON merge patch, only the fields present in the body are changed. A null end_date makes the subscription open-ended.",
+                "consumes": [
+                    "application/json",
+                    "application/merge-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscription"
+                ],
+                "summary": "Partially update subscription by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Subscription patch data",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.PatchSubRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateSubResponce"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "new subscription version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/history": {
+            "get": {
+                "description": "Returns the audit trail of the subscription with snapshots before and after every change.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscription"
+                ],
+                "summary": "Read subscription change history",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.HistoryResponce"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/price": {
+            "get": {
+                "description": "Returns the scheduled price changes of the subscription ordered by effective date.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscription"
+                ],
+                "summary": "Read subscription price changes",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.PriceChangeResponce"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Sets the subscription price from the given month on, cost of earlier months keeps the previous price. A change for the same month is replaced. The change is recorded in the history and bumps the subscription version.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscription"
+                ],
+                "summary": "Schedule subscription price change",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the subscription, * to skip the check",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Price change data",
+                        "name": "price",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.PriceChangeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateSubResponce"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "new subscription version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/restore": {
+            "post": {
+                "description": "Brings back a soft-deleted subscription that has not been purged yet.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscription"
+                ],
+                "summary": "Restore deleted subscription by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateSubResponce"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "new subscription version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Problem"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "dto.CostGroup": {
+            "type": "object",
+            "properties": {
+                "charges_count": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "cost": {
+                    "type": "string",
+                    "example": "900"
+                },
+                "months_count": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "service_name": {
+                    "type": "string",
+                    "example": "Yandex Plus"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "dceb1963-e152-47ff-a562-81a360627309"
+                }
+            }
+        },
+        "dto.CostItem": {
+            "type": "object",
+            "properties": {
+                "billing_period": {
+                    "type": "string",
+                    "example": "monthly"
+                },
+                "charges_count": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "cost": {
+                    "type": "string",
+                    "example": "900"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "months_count": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "service_name": {
+                    "type": "string",
+                    "example": "Yandex Plus"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "dceb1963-e152-47ff-a562-81a360627309"
+                }
+            }
         },
-        "dto.CostResponce": {
+        "dto.CostMonth": {
+            "type": "object",
+            "properties": {
+                "cost": {
+                    "type": "string",
+                    "example": "400"
+                },
+                "month": {
+                    "type": "string",
+                    "example": "01-2025"
+                },
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CostMonthItem"
+                    }
+                }
+            }
+        },
+        "dto.CostMonthItem": {
+            "type": "object",
+            "properties": {
+                "billing_period": {
+                    "type": "string",
+                    "example": "monthly"
+                },
+                "charges_count": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "cost": {
+                    "type": "string",
+                    "example": "400"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "service_name": {
+                    "type": "string",
+                    "example": "Yandex Plus"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "dceb1963-e152-47ff-a562-81a360627309"
+                }
+            }
+        },
+        "dto.CostMonthlyResponce": {
             "type": "object",
             "properties": {
                 "cost": {
+                    "type": "string",
+                    "example": "1200"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "months": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CostMonth"
+                    }
+                },
+                "service_name": {
+                    "type": "string",
+                    "example": "Yandex Plus"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "dceb1963-e152-47ff-a562-81a360627309"
+                }
+            }
+        },
+        "dto.CostRequest": {
+            "type": "object",
+            "required": [
+                "end_date",
+                "start_date"
+            ],
+            "properties": {
+                "end_date": {
+                    "type": "string",
+                    "example": "02-2025"
+                },
+                "prorate": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "service_name": {
+                    "type": "string",
+                    "example": "Yandex Plus"
+                },
+                "start_date": {
+                    "type": "string",
+                    "example": "01-2025"
+                },
+                "target_currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "dceb1963-e152-47ff-a562-81a360627309"
+                }
+            }
+        },
+        "dto.CostResponce": {
+            "type": "object",
+            "properties": {
+                "charges_count": {
                     "type": "integer",
-                    "example": 900
+                    "example": 3
+                },
+                "cost": {
+                    "type": "string",
+                    "example": "900"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CostGroup"
+                    }
                 },
                 "months_count": {
                     "type": "integer",
@@ -348,6 +1146,12 @@ const docTemplate = `{
                     "type": "string",
                     "example": "Yandex Plus"
                 },
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CostItem"
+                    }
+                },
                 "user_id": {
                     "type": "string",
                     "example": "dceb1963-e152-47ff-a562-81a360627309"
@@ -356,22 +1160,43 @@ const docTemplate = `{
         },
         "dto.CreateSubRequest": {
             "type": "object",
+            "required": [
+                "price",
+                "service_name",
+                "start_date",
+                "user_id"
+            ],
             "properties": {
+                "billing_period": {
+                    "type": "string",
+                    "enum": [
+                        "weekly",
+                        "monthly",
+                        "quarterly",
+                        "annual"
+                    ],
+                    "example": "monthly"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
                 "end_date": {
                     "type": "string",
-                    "example": "02-2025"
+                    "example": "16-09-2025"
                 },
                 "price": {
-                    "type": "integer",
-                    "example": 400
+                    "type": "string",
+                    "example": "399.99"
                 },
                 "service_name": {
                     "type": "string",
+                    "maxLength": 255,
                     "example": "Yandex Plus"
                 },
                 "start_date": {
                     "type": "string",
-                    "example": "01-2025"
+                    "example": "17-03-2025"
                 },
                 "user_id": {
                     "type": "string",
@@ -401,20 +1226,136 @@ const docTemplate = `{
                 }
             }
         },
+        "dto.ExchangeRateRequest": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "month": {
+                    "type": "string",
+                    "example": "01-2025"
+                },
+                "rate": {
+                    "type": "string",
+                    "example": "92.5"
+                }
+            }
+        },
+        "dto.ExchangeRateResponce": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "month": {
+                    "type": "string",
+                    "example": "01-2025"
+                },
+                "rate": {
+                    "type": "string",
+                    "example": "92.5"
+                }
+            }
+        },
+        "dto.HistoryResponce": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "update"
+                },
+                "actor": {
+                    "type": "string",
+                    "example": "admin"
+                },
+                "after": {
+                    "$ref": "#/definitions/dto.LoadSubResponce"
+                },
+                "before": {
+                    "$ref": "#/definitions/dto.LoadSubResponce"
+                },
+                "changed_at": {
+                    "type": "string",
+                    "example": "2025-01-02T15:04:05Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "price_change": {
+                    "$ref": "#/definitions/dto.PriceChangeResponce"
+                }
+            }
+        },
+        "dto.ImportRatesResponce": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "dto.LoadListResponce": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.LoadSubResponce"
+                    }
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "next_cursor": {
+                    "type": "string",
+                    "example": "eyJzIjoiIiwibyI6IiIsInYiOiIxMCIsImlkIjoxMH0"
+                },
+                "offset": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
         "dto.LoadSubResponce": {
             "type": "object",
             "properties": {
+                "billing_period": {
+                    "type": "string",
+                    "example": "monthly"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "current_price": {
+                    "type": "string",
+                    "example": "499.99"
+                },
                 "end_date": {
                     "type": "string",
-                    "example": "02-2025"
+                    "example": "16-09-2025"
                 },
                 "id": {
                     "type": "integer",
                     "example": 1
                 },
                 "price": {
-                    "type": "integer",
-                    "example": 400
+                    "type": "string",
+                    "example": "399.99"
                 },
                 "service_name": {
                     "type": "string",
@@ -422,36 +1363,52 @@ const docTemplate = `{
                 },
                 "start_date": {
                     "type": "string",
-                    "example": "01-2025"
+                    "example": "17-03-2025"
                 },
                 "user_id": {
                     "type": "string",
                     "example": "dceb1963-e152-47ff-a562-81a360627309"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 1
                 }
             }
         },
-        "dto.UpdateSubRequest": {
+        "dto.PatchSubRequest": {
             "type": "object",
             "properties": {
+                "billing_period": {
+                    "type": "string",
+                    "enum": [
+                        "weekly",
+                        "monthly",
+                        "quarterly",
+                        "annual"
+                    ],
+                    "example": "annual"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
                 "end_date": {
                     "type": "string",
                     "example": "07-2025"
                 },
-                "id": {
-                    "type": "integer",
-                    "example": 1
-                },
                 "price": {
-                    "type": "integer",
-                    "example": 399
+                    "type": "string",
+                    "example": "399.99"
                 },
                 "service_name": {
                     "type": "string",
+                    "maxLength": 255,
+                    "minLength": 1,
                     "example": "Yandex Minus"
                 },
                 "start_date": {
                     "type": "string",
-                    "example": "05-2025"
+                    "example": "17-05-2025"
                 },
                 "user_id": {
                     "type": "string",
@@ -459,63 +1416,132 @@ const docTemplate = `{
                 }
             }
         },
-        "dto.UpdateSubResponce": {
+        "dto.PriceChangeRequest": {
             "type": "object",
+            "required": [
+                "effective_from",
+                "price"
+            ],
             "properties": {
-                "success": {
-                    "type": "boolean",
-                    "example": true
+                "effective_from": {
+                    "type": "string",
+                    "example": "03-2025"
+                },
+                "price": {
+                    "type": "string",
+                    "example": "499.99"
                 }
             }
         },
-        "handler.ErrorBadRequest": {
+        "dto.PriceChangeResponce": {
             "type": "object",
             "properties": {
-                "message": {
+                "effective_from": {
                     "type": "string",
-                    "example": "error text"
+                    "example": "03-2025"
                 },
-                "status": {
+                "price": {
                     "type": "string",
-                    "example": "bad request"
-                },
-                "success": {
-                    "type": "boolean",
-                    "example": false
+                    "example": "499.99"
                 }
             }
         },
-        "handler.ErrorInternalError": {
+        "dto.UpdateSubRequest": {
             "type": "object",
+            "required": [
+                "price",
+                "service_name",
+                "start_date",
+                "user_id"
+            ],
             "properties": {
-                "message": {
+                "billing_period": {
                     "type": "string",
-                    "example": "error text"
+                    "enum": [
+                        "weekly",
+                        "monthly",
+                        "quarterly",
+                        "annual"
+                    ],
+                    "example": "monthly"
                 },
-                "status": {
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "end_date": {
+                    "type": "string",
+                    "example": "07-2025"
+                },
+                "price": {
+                    "type": "string",
+                    "example": "399.99"
+                },
+                "service_name": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Yandex Minus"
+                },
+                "start_date": {
                     "type": "string",
-                    "example": "internal error"
+                    "example": "17-05-2025"
                 },
+                "user_id": {
+                    "type": "string",
+                    "example": "dceb1963-e152-47ff-a562-81a360627309"
+                }
+            }
+        },
+        "dto.UpdateSubResponce": {
+            "type": "object",
+            "properties": {
                 "success": {
                     "type": "boolean",
-                    "example": false
+                    "example": true
                 }
             }
         },
-        "handler.ErrorNotFound": {
+        "handler.FieldError": {
             "type": "object",
             "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "date"
+                },
+                "field": {
+                    "type": "string",
+                    "example": "start_date"
+                },
                 "message": {
                     "type": "string",
-                    "example": "error text"
+                    "example": "expected DD-MM-YYYY, YYYY-MM-DD, MM-YYYY or YYYY-MM"
+                }
+            }
+        },
+        "handler.Problem": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "string",
+                    "example": "request validation failed"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.FieldError"
+                    }
                 },
                 "status": {
+                    "type": "integer",
+                    "example": 400
+                },
+                "title": {
                     "type": "string",
-                    "example": "entity not found"
+                    "example": "Bad Request"
                 },
-                "success": {
-                    "type": "boolean",
-                    "example": false
+                "type": {
+                    "type": "string",
+                    "example": "about:blank"
                 }
             }
         }
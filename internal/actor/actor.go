@@ -0,0 +1,22 @@
+package actor
+
+import "context"
+
+// Anonymous is recorded when a change is made without a known actor.
+const Anonymous = "anonymous"
+
+type ctxKey struct{}
+
+// WithActor returns a copy of ctx carrying the name of who makes the changes.
+func WithActor(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, name)
+}
+
+// FromContext returns the actor stored in ctx or Anonymous.
+func FromContext(ctx context.Context) string {
+	name, ok := ctx.Value(ctxKey{}).(string)
+	if !ok || name == "" {
+		return Anonymous
+	}
+	return name
+}
@@ -2,11 +2,8 @@ package app
 
 import (
 	"context"
+	"database/sql"
 	"log"
-	"main/internal/config"
-	"main/internal/handler"
-	"main/internal/interfaces"
-	"main/pkg/postgres"
 	"net/http"
 	"os"
 	"os/signal"
@@ -15,7 +12,19 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/sirupsen/logrus"
+
+	"github.com/hollisgr/subservice/internal/actor"
+	"github.com/hollisgr/subservice/internal/config"
+	"github.com/hollisgr/subservice/internal/db"
+	"github.com/hollisgr/subservice/internal/db/memory"
+	sqlitedb "github.com/hollisgr/subservice/internal/db/sqlite"
+	"github.com/hollisgr/subservice/internal/handler"
+	"github.com/hollisgr/subservice/internal/interfaces"
+	"github.com/hollisgr/subservice/internal/migrate"
+	"github.com/hollisgr/subservice/pkg/postgres"
+	"github.com/hollisgr/subservice/pkg/sqlite"
 )
 
 // ConnectToDB establishes a connection pool to PostgreSQL database using given configuration.
@@ -34,6 +43,55 @@ func ConnectToDB(cfg *config.Config) *pgxpool.Pool {
 	return pgxPool
 }
 
+// SetupStorage creates the storage selected by the configured driver and
+// returns it with a function releasing its resources.
+func SetupStorage(cfg *config.Config) (interfaces.Storage, func()) {
+	switch cfg.Storage.Driver {
+	case config.DriverPostgres:
+		pgxPool := ConnectToDB(cfg)
+		return db.New(pgxPool), pgxPool.Close
+	case config.DriverSQLite:
+		conn := ConnectToSQLite(cfg)
+		return sqlitedb.New(conn), func() { conn.Close() }
+	case config.DriverMemory:
+		log.Println("Using in-memory storage, data is lost on shutdown")
+		return memory.New(), func() {}
+	default:
+		log.Fatalln("unknown storage driver:", cfg.Storage.Driver)
+		return nil, nil
+	}
+}
+
+// ConnectToSQLite opens the SQLite database file given in configuration.
+func ConnectToSQLite(cfg *config.Config) *sql.DB {
+	conn, err := sqlite.Open(context.Background(), cfg.SQLite.Path)
+	if err != nil {
+		log.Fatalln("cant open sqlite db, err:", err)
+	}
+	log.Println("Connection to sqlite database OK:", cfg.SQLite.Path)
+	return conn
+}
+
+// Migrate runs the migrate command (up, down or status) against the database
+// of the configured storage driver, the in-memory storage needs no migrations.
+func Migrate(ctx context.Context, cfg *config.Config, command string) error {
+	switch cfg.Storage.Driver {
+	case config.DriverPostgres:
+		pgxPool := ConnectToDB(cfg)
+		defer pgxPool.Close()
+		conn := stdlib.OpenDBFromPool(pgxPool)
+		defer conn.Close()
+		return migrate.Run(ctx, cfg.Storage.Driver, conn, command, os.Stdout)
+	case config.DriverSQLite:
+		conn := ConnectToSQLite(cfg)
+		defer conn.Close()
+		return migrate.Run(ctx, cfg.Storage.Driver, conn, command, os.Stdout)
+	default:
+		log.Println("Storage driver", cfg.Storage.Driver, "needs no migrations")
+		return nil
+	}
+}
+
 func SetupLogger(level string) {
 	lvl, err := logrus.ParseLevel(level)
 	if err != nil {
@@ -52,13 +110,43 @@ func SetupLogger(level string) {
 }
 
 // SetupRouter configures and returns a gin.Engine instance with registered wallet handlers.
-func SetupRouter(sub interfaces.Subscriptions) *gin.Engine {
+func SetupRouter(sub interfaces.Subscriptions, rates interfaces.Rates) *gin.Engine {
 	r := gin.Default()
-	h := handler.New(r, sub)
+	h := handler.New(r, sub, rates)
 	h.Register()
 	return r
 }
 
+// StartPurge periodically removes soft-deleted subscriptions older than the
+// configured retention period until ctx is cancelled. A zero period disables it.
+func StartPurge(ctx context.Context, cfg *config.Config, sub interfaces.Subscriptions) {
+	if cfg.Retention.Period <= 0 || cfg.Retention.PurgeInterval <= 0 {
+		log.Println("Retention purge disabled")
+		return
+	}
+
+	ctx = actor.WithActor(ctx, "retention purge")
+
+	go func() {
+		ticker := time.NewTicker(cfg.Retention.PurgeInterval)
+		defer ticker.Stop()
+
+		for {
+			_, err := sub.Purge(ctx, cfg.Retention.Period)
+			if err != nil {
+				logrus.Error("retention purge err: ", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	log.Println("Retention purge started, period:", cfg.Retention.Period)
+}
+
 // SetupServer creates and returns an http.Server instance based on configuration and router.
 func SetupServer(cfg *config.Config, r *gin.Engine) *http.Server {
 	srv := &http.Server{
@@ -4,11 +4,25 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
+// Storage drivers selectable with STORAGE_DRIVER.
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory"
+)
+
 type Config struct {
+	Storage struct {
+		Driver string `env:"STORAGE_DRIVER" env-default:"postgres"`
+	}
+	Migrate struct {
+		OnStart bool `env:"MIGRATE_ON_START" env-default:"false"`
+	}
 	Listen struct {
 		Addr   string
 		BindIP string `env:"BIND_IP"`
@@ -22,12 +36,19 @@ type Config struct {
 		Username string `env:"PSQL_USER"`
 		Password string `env:"PSQL_PASSWORD"`
 	}
+	SQLite struct {
+		Path string `env:"SQLITE_PATH" env-default:"subservice.db"`
+	}
 	Logger struct {
 		LogLevel string `env:"LOG_LEVEL"`
 	}
 	CORS struct {
 		AllowOrigins []string `env:"CORS_ALLOW_ORIGINS"`
 	}
+	Retention struct {
+		Period        time.Duration `env:"RETENTION_PERIOD" env-default:"720h"`
+		PurgeInterval time.Duration `env:"PURGE_INTERVAL" env-default:"1h"`
+	}
 }
 
 var instance *Config
@@ -0,0 +1,118 @@
+package dates
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hollisgr/subservice/internal/domain"
+)
+
+// Years outside of the range are rejected.
+const (
+	MinYear = 1999
+	MaxYear = 2099
+)
+
+var (
+	ErrFormat = errors.New("expected DD-MM-YYYY, YYYY-MM-DD, MM-YYYY or YYYY-MM")
+	ErrRange  = errors.New("no such date between 1999 and 2099")
+)
+
+// ParseError is a malformed date of a request field, it wraps ErrFormat or ErrRange.
+type ParseError struct {
+	Field string
+	Value string
+	Err   error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s %q: %v", e.Field, e.Value, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes a ParseError a validation domain error.
+func (e *ParseError) Is(target error) bool {
+	return target == domain.ErrValidation
+}
+
+// Parse parses a DD-MM-YYYY, YYYY-MM-DD, MM-YYYY or YYYY-MM date of the field,
+// month reports whether only month and year were given, the date is then the first day.
+func Parse(field, str string) (date time.Time, month bool, err error) {
+	parts := strings.Split(str, "-")
+	nums, ok := numbers(parts)
+
+	var day, mon, year int
+	switch {
+	case !ok:
+	case len(nums) == 3 && len(parts[0]) == 4:
+		year, mon, day = nums[0], nums[1], nums[2]
+	case len(nums) == 3:
+		day, mon, year = nums[0], nums[1], nums[2]
+	case len(nums) == 2 && len(parts[0]) == 4:
+		year, mon, day, month = nums[0], nums[1], 1, true
+	case len(nums) == 2:
+		mon, year, day, month = nums[0], nums[1], 1, true
+	default:
+		ok = false
+	}
+	if !ok {
+		return time.Time{}, false, &ParseError{Field: field, Value: str, Err: ErrFormat}
+	}
+
+	date = time.Date(year, time.Month(mon), day, 0, 0, 0, 0, time.UTC)
+
+	// time.Date нормализует 31-02 в 03-03, такие даты не принимаем
+	if year < MinYear || year > MaxYear || date.Day() != day || int(date.Month()) != mon {
+		return time.Time{}, false, &ParseError{Field: field, Value: str, Err: ErrRange}
+	}
+	return date, month, nil
+}
+
+// ParseStart parses a start date, a month means its first day.
+func ParseStart(field, str string) (time.Time, error) {
+	date, _, err := Parse(field, str)
+	return date, err
+}
+
+// ParseEnd parses an inclusive end date, a month means its last day.
+func ParseEnd(field, str string) (time.Time, error) {
+	date, month, err := Parse(field, str)
+	if err != nil {
+		return date, err
+	}
+	if month {
+		date = date.AddDate(0, 1, -1)
+	}
+	return date, nil
+}
+
+// ParseMonth parses a month, a full date means the month it falls into.
+func ParseMonth(field, str string) (time.Time, error) {
+	date, _, err := Parse(field, str)
+	if err != nil {
+		return date, err
+	}
+	return date.AddDate(0, 0, 1-date.Day()), nil
+}
+
+// numbers parses the date parts as unsigned decimals of at most 4 digits.
+func numbers(parts []string) ([]int, bool) {
+	res := make([]int, 0, len(parts))
+	for _, part := range parts {
+		if len(part) == 0 || len(part) > 4 || strings.TrimLeft(part, "0123456789") != "" {
+			return nil, false
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, false
+		}
+		res = append(res, n)
+	}
+	return res, true
+}
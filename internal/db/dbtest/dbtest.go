@@ -0,0 +1,577 @@
+// Package dbtest is a conformance suite every interfaces.Storage implementation
+// must pass. Call Run from a test of the implementation:
+//
+//	func TestStorage(t *testing.T) {
+//		dbtest.Run(t, func(t *testing.T) interfaces.Storage {
+//			return memory.New()
+//		})
+//	}
+//
+// The factory is called for every subtest and must return an empty storage.
+package dbtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/hollisgr/subservice/internal/actor"
+	"github.com/hollisgr/subservice/internal/domain"
+	"github.com/hollisgr/subservice/internal/dto"
+	"github.com/hollisgr/subservice/internal/interfaces"
+	"github.com/hollisgr/subservice/internal/model"
+)
+
+// Factory returns an empty storage for one subtest.
+type Factory func(t *testing.T) interfaces.Storage
+
+var (
+	userA = uuid.MustParse("00000000-0000-0000-0000-00000000000a")
+	userB = uuid.MustParse("00000000-0000-0000-0000-00000000000b")
+)
+
+// Run runs the conformance suite against the storages made by newStorage.
+func Run(t *testing.T, newStorage Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s interfaces.Storage)
+	}{
+		{"CreateLoad", testCreateLoad},
+		{"NotFound", testNotFound},
+		{"UpdateVersion", testUpdateVersion},
+		{"DeleteRestore", testDeleteRestore},
+		{"History", testHistory},
+		{"Purge", testPurge},
+		{"ListFilters", testListFilters},
+		{"ListSort", testListSort},
+		{"ListSortServiceName", testListSortServiceName},
+		{"ListCursor", testListCursor},
+		{"CostOverlap", testCostOverlap},
+		{"Prices", testPrices},
+		{"PriceVersion", testPriceVersion},
+		{"Rates", testRates},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStorage(t))
+		})
+	}
+}
+
+func testCreateLoad(t *testing.T, s interfaces.Storage) {
+	ctx := context.Background()
+	end := date(2025, 12, 31)
+	want := model.Subscription{
+		ServiceName:   "Yandex Plus",
+		Price:         decimal.RequireFromString("399.99"),
+		UserId:        userA,
+		StartDate:     date(2025, 7, 15),
+		EndDate:       &end,
+		BillingPeriod: model.PeriodQuarterly,
+		Currency:      "USD",
+	}
+
+	id, err := s.Create(ctx, want)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	want.Id = id
+	want.Version = 1
+
+	got, err := s.Load(ctx, id)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	assertSubscription(t, got, want)
+
+	other, err := s.Create(ctx, subscription("Okko", "100", userA, date(2025, 1, 1), nil))
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if other == id {
+		t.Fatalf("create returned the same id %d twice", id)
+	}
+}
+
+func testNotFound(t *testing.T, s interfaces.Storage) {
+	ctx := context.Background()
+	const missing = 1_000_000
+
+	_, err := s.Load(ctx, missing)
+	assertError(t, "load", err, domain.ErrSubscriptionNotFound)
+
+	sub := subscription("Okko", "100", userA, date(2025, 1, 1), nil)
+	sub.Id = missing
+	_, err = s.Update(ctx, sub)
+	assertError(t, "update", err, domain.ErrSubscriptionNotFound)
+
+	err = s.Delete(ctx, missing, 0)
+	assertError(t, "delete", err, domain.ErrSubscriptionNotFound)
+
+	_, err = s.Restore(ctx, missing)
+	assertError(t, "restore", err, domain.ErrSubscriptionNotFound)
+
+	// An empty window is not an error, just an empty result.
+	subs, err := s.Cost(ctx, dto.CostRequestToDB{StartDate: date(2025, 1, 1), EndDate: date(2025, 12, 31)})
+	if err != nil {
+		t.Fatalf("cost: %v", err)
+	}
+	assertIds(t, "cost", subs, nil)
+}
+
+func testUpdateVersion(t *testing.T, s interfaces.Storage) {
+	ctx := context.Background()
+	id := create(t, s, subscription("Okko", "100", userA, date(2025, 1, 1), nil))
+
+	sub := subscription("Okko Premium", "150.5", userB, date(2025, 2, 1), nil)
+	sub.Id = id
+	sub.Version = 2
+	_, err := s.Update(ctx, sub)
+	assertError(t, "update with a stale version", err, domain.ErrVersionMismatch)
+
+	sub.Version = 1
+	version, err := s.Update(ctx, sub)
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if version != 2 {
+		t.Fatalf("update returned version %d, want 2", version)
+	}
+
+	sub.Version = 0
+	version, err = s.Update(ctx, sub)
+	if err != nil {
+		t.Fatalf("update without a version: %v", err)
+	}
+	if version != 3 {
+		t.Fatalf("update returned version %d, want 3", version)
+	}
+
+	got := load(t, s, id)
+	sub.Version = 3
+	assertSubscription(t, got, sub)
+}
+
+func testDeleteRestore(t *testing.T, s interfaces.Storage) {
+	ctx := context.Background()
+	id := create(t, s, subscription("Okko", "100", userA, date(2025, 1, 1), nil))
+
+	err := s.Delete(ctx, id, 5)
+	assertError(t, "delete with a stale version", err, domain.ErrVersionMismatch)
+
+	err = s.Delete(ctx, id, 1)
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	_, err = s.Load(ctx, id)
+	assertError(t, "load deleted", err, domain.ErrSubscriptionNotFound)
+	err = s.Delete(ctx, id, 0)
+	assertError(t, "delete deleted", err, domain.ErrSubscriptionNotFound)
+	assertCount(t, s, dto.LoadListRequestToDB{}, 0)
+
+	version, err := s.Restore(ctx, id)
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if version != 3 {
+		t.Fatalf("restore returned version %d, want 3", version)
+	}
+	if got := load(t, s, id); got.Version != 3 {
+		t.Fatalf("restored version %d, want 3", got.Version)
+	}
+
+	_, err = s.Restore(ctx, id)
+	assertError(t, "restore not deleted", err, domain.ErrNotDeleted)
+}
+
+func testHistory(t *testing.T, s interfaces.Storage) {
+	ctx := actor.WithActor(context.Background(), "alice")
+	sub := subscription("Okko", "100", userA, date(2025, 1, 1), nil)
+	id := create(t, s, sub)
+
+	sub.Id = id
+	sub.Price = decimal.RequireFromString("120")
+	_, err := s.Update(ctx, sub)
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	err = s.Delete(context.Background(), id, 0)
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	_, err = s.Restore(ctx, id)
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+
+	entries, err := s.History(ctx, id)
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+
+	want := []struct {
+		action string
+		actor  string
+		before bool
+		after  bool
+	}{
+		{model.ActionCreate, actor.Anonymous, false, true},
+		{model.ActionUpdate, "alice", true, true},
+		{model.ActionDelete, actor.Anonymous, true, false},
+		{model.ActionRestore, "alice", false, true},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("history has %d entries, want %d", len(entries), len(want))
+	}
+	for i, w := range want {
+		e := entries[i]
+		if e.SubscriptionId != id || e.Action != w.action || e.Actor != w.actor ||
+			(e.Before != nil) != w.before || (e.After != nil) != w.after {
+			t.Errorf("history entry %d = %s by %s (before %t, after %t), want %s by %s (before %t, after %t)",
+				i, e.Action, e.Actor, e.Before != nil, e.After != nil, w.action, w.actor, w.before, w.after)
+		}
+	}
+	if !entries[1].Before.Price.Equal(decimal.RequireFromString("100")) ||
+		!entries[1].After.Price.Equal(decimal.RequireFromString("120")) {
+		t.Errorf("update snapshots have prices %s and %s, want 100 and 120",
+			entries[1].Before.Price, entries[1].After.Price)
+	}
+
+	others, err := s.History(ctx, id+1)
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(others) != 0 {
+		t.Errorf("history of another subscription has %d entries, want 0", len(others))
+	}
+}
+
+func testPurge(t *testing.T, s interfaces.Storage) {
+	ctx := context.Background()
+	deleted := create(t, s, subscription("Okko", "100", userA, date(2025, 1, 1), nil))
+	kept := create(t, s, subscription("Kion", "200", userA, date(2025, 1, 1), nil))
+
+	_, err := s.SetPrice(ctx, model.PriceChange{SubscriptionId: deleted, EffectiveFrom: date(2025, 3, 1), Price: decimal.NewFromInt(150)}, 0)
+	if err != nil {
+		t.Fatalf("set price: %v", err)
+	}
+	err = s.Delete(ctx, deleted, 0)
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	n, err := s.Purge(ctx, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if n != 0 {
+		t.Fatalf("purge before the deletion removed %d, want 0", n)
+	}
+
+	n, err = s.Purge(ctx, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("purge removed %d, want 1", n)
+	}
+
+	_, err = s.Restore(ctx, deleted)
+	assertError(t, "restore purged", err, domain.ErrSubscriptionNotFound)
+	load(t, s, kept)
+
+	prices, err := s.Prices(ctx, []int{deleted})
+	if err != nil {
+		t.Fatalf("prices: %v", err)
+	}
+	if len(prices) != 0 {
+		t.Errorf("purged subscription has %d price changes, want 0", len(prices))
+	}
+
+	entries, err := s.History(ctx, deleted)
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(entries) != 4 || entries[3].Action != model.ActionPurge || entries[3].Before == nil {
+		t.Errorf("history of purged subscription does not end with a purge entry: %+v", entries)
+	}
+}
+
+func testListFilters(t *testing.T, s interfaces.Storage) {
+	ctx := context.Background()
+	march := date(2025, 3, 31)
+	a := create(t, s, subscription("Yandex Plus", "299", userA, date(2025, 1, 1), nil))
+	b := create(t, s, subscription("yandex music", "149.5", userB, date(2025, 2, 10), &march))
+	c := create(t, s, subscription("Okko", "399", userA, date(2025, 4, 1), nil))
+	d := create(t, s, subscription("100%_Off", "50", userB, date(2025, 1, 1), nil))
+	deleted := create(t, s, subscription("Yandex Plus", "299", userA, date(2025, 1, 1), nil))
+	err := s.Delete(ctx, deleted, 0)
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	priceMin := decimal.RequireFromString("149.5")
+	priceMax := decimal.RequireFromString("299")
+	tests := []struct {
+		name   string
+		filter dto.LoadListRequestToDB
+		want   []int
+	}{
+		{"all", dto.LoadListRequestToDB{}, []int{a, b, c, d}},
+		{"user", dto.LoadListRequestToDB{UserId: userA}, []int{a, c}},
+		{"service name", dto.LoadListRequestToDB{ServiceName: "Okko"}, []int{c}},
+		{"case-insensitive prefix", dto.LoadListRequestToDB{ServicePrefix: "YANDEX"}, []int{a, b}},
+		{"literal prefix", dto.LoadListRequestToDB{ServicePrefix: "100%_"}, []int{d}},
+		{"wildcards are not patterns", dto.LoadListRequestToDB{ServicePrefix: "%"}, nil},
+		{"price range", dto.LoadListRequestToDB{PriceMin: &priceMin, PriceMax: &priceMax}, []int{a, b}},
+		{"active on the end date", dto.LoadListRequestToDB{ActiveMonth: date(2025, 3, 1)}, []int{a, b, d}},
+		{"ended before the month", dto.LoadListRequestToDB{ActiveMonth: date(2025, 4, 1)}, []int{a, c, d}},
+		{"started after the month", dto.LoadListRequestToDB{ActiveMonth: date(2024, 12, 1)}, nil},
+	}
+	for _, tt := range tests {
+		assertCount(t, s, tt.filter, len(tt.want))
+		tt.filter.Limit = 10
+		assertList(t, s, tt.name, tt.filter, tt.want)
+	}
+}
+
+func testListSort(t *testing.T, s interfaces.Storage) {
+	ends := date(2025, 6, 30)
+	endsEarlier := date(2025, 3, 31)
+	a := create(t, s, subscription("b", "200", userA, date(2025, 2, 1), nil))
+	b := create(t, s, subscription("a", "300", userA, date(2025, 1, 1), &ends))
+	c := create(t, s, subscription("c", "100", userA, date(2025, 1, 1), &endsEarlier))
+	d := create(t, s, subscription("a", "200", userA, date(2025, 3, 1), nil))
+
+	tests := []struct {
+		sort  string
+		order string
+		want  []int
+	}{
+		{"", "", []int{a, b, c, d}},
+		{"id", "desc", []int{d, c, b, a}},
+		{"unknown", "", []int{a, b, c, d}},
+		{"price", "", []int{c, a, d, b}},
+		{"price", "desc", []int{b, d, a, c}},
+		{"start_date", "", []int{b, c, a, d}},
+		{"end_date", "", []int{c, b, a, d}},
+		{"end_date", "desc", []int{d, a, b, c}},
+		{"service_name", "", []int{b, d, a, c}},
+	}
+	for _, tt := range tests {
+		filter := dto.LoadListRequestToDB{Limit: 10, Sort: tt.sort, Order: tt.order}
+		assertList(t, s, tt.sort+" "+tt.order, filter, tt.want)
+	}
+
+	assertList(t, s, "limit and offset", dto.LoadListRequestToDB{Limit: 2, Offset: 1}, []int{b, c})
+	assertList(t, s, "offset past the end", dto.LoadListRequestToDB{Limit: 2, Offset: 10}, nil)
+}
+
+// testListSortServiceName checks that service names are sorted
+// case-insensitively in byte order of the lower-cased names.
+func testListSortServiceName(t *testing.T, s interfaces.Storage) {
+	kion := create(t, s, subscription("kion", "100", userA, date(2025, 1, 1), nil))
+	okko := create(t, s, subscription("Okko", "100", userA, date(2025, 1, 1), nil))
+	apple := create(t, s, subscription("Apple TV", "100", userA, date(2025, 1, 1), nil))
+	lowerOkko := create(t, s, subscription("okko", "100", userA, date(2025, 1, 1), nil))
+	plus := create(t, s, subscription("Яндекс Плюс", "100", userA, date(2025, 1, 1), nil))
+	music := create(t, s, subscription("яндекс музыка", "100", userA, date(2025, 1, 1), nil))
+	zvuk := create(t, s, subscription("Zvuk", "100", userA, date(2025, 1, 1), nil))
+
+	asc := []int{apple, kion, okko, lowerOkko, zvuk, music, plus}
+	assertList(t, s, "service_name", dto.LoadListRequestToDB{Limit: 10, Sort: "service_name"}, asc)
+	assertList(t, s, "service_name desc", dto.LoadListRequestToDB{Limit: 10, Sort: "service_name", Order: "desc"},
+		[]int{plus, music, zvuk, lowerOkko, okko, kion, apple})
+
+	after := &dto.ListCursor{Value: "Okko", Id: okko}
+	assertList(t, s, "service_name cursor", dto.LoadListRequestToDB{Limit: 10, Sort: "service_name", After: after},
+		[]int{lowerOkko, zvuk, music, plus})
+}
+
+func testListCursor(t *testing.T, s interfaces.Storage) {
+	a := create(t, s, subscription("b", "200", userA, date(2025, 2, 1), nil))
+	b := create(t, s, subscription("a", "300", userA, date(2025, 1, 1), nil))
+	c := create(t, s, subscription("c", "200", userA, date(2025, 1, 1), nil))
+
+	tests := []struct {
+		name   string
+		filter dto.LoadListRequestToDB
+		want   []int
+	}{
+		{"id", dto.LoadListRequestToDB{After: &dto.ListCursor{Value: itoa(a), Id: a}}, []int{b, c}},
+		{"price ties by id", dto.LoadListRequestToDB{Sort: "price", After: &dto.ListCursor{Value: "200", Id: a}}, []int{c, b}},
+		{"price desc", dto.LoadListRequestToDB{Sort: "price", Order: "desc", After: &dto.ListCursor{Value: "300", Id: b}}, []int{c, a}},
+		{"start date", dto.LoadListRequestToDB{Sort: "start_date", After: &dto.ListCursor{Value: "2025-01-01", Id: c}}, []int{a}},
+		{"open end", dto.LoadListRequestToDB{Sort: "end_date", After: &dto.ListCursor{Value: "infinity", Id: a}}, []int{b, c}},
+		{"service name", dto.LoadListRequestToDB{Sort: "service_name", After: &dto.ListCursor{Value: "a", Id: b}}, []int{a, c}},
+	}
+	for _, tt := range tests {
+		tt.filter.Limit = 10
+		assertList(t, s, tt.name, tt.filter, tt.want)
+	}
+}
+
+func testCostOverlap(t *testing.T, s interfaces.Storage) {
+	ctx := context.Background()
+	endsOnStart := date(2025, 3, 1)
+	endsBefore := date(2025, 2, 28)
+	open := create(t, s, subscription("Okko", "100", userA, date(2024, 1, 1), nil))
+	ending := create(t, s, subscription("Okko", "100", userA, date(2024, 6, 1), &endsOnStart))
+	create(t, s, subscription("Okko", "100", userA, date(2024, 6, 1), &endsBefore))
+	starting := create(t, s, subscription("Kion", "100", userB, date(2025, 3, 31), nil))
+	create(t, s, subscription("Kion", "100", userB, date(2025, 4, 1), nil))
+	other := create(t, s, subscription("Okko", "100", userB, date(2025, 3, 15), nil))
+	deleted := create(t, s, subscription("Okko", "100", userA, date(2025, 3, 1), nil))
+	err := s.Delete(ctx, deleted, 0)
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	// Cost is ordered by service name, user id and start date.
+	tests := []struct {
+		name string
+		data dto.CostRequestToDB
+		want []int
+	}{
+		{"period", dto.CostRequestToDB{}, []int{starting, open, ending, other}},
+		{"service name", dto.CostRequestToDB{ServiceName: "Okko"}, []int{open, ending, other}},
+		{"user", dto.CostRequestToDB{UserId: userB}, []int{starting, other}},
+		{"service name and user", dto.CostRequestToDB{ServiceName: "Kion", UserId: userA}, nil},
+	}
+	for _, tt := range tests {
+		tt.data.StartDate = date(2025, 3, 1)
+		tt.data.EndDate = date(2025, 3, 31)
+		subs, err := s.Cost(ctx, tt.data)
+		if err != nil {
+			t.Fatalf("cost %s: %v", tt.name, err)
+		}
+		assertIds(t, "cost "+tt.name, subs, tt.want)
+	}
+}
+
+func testPrices(t *testing.T, s interfaces.Storage) {
+	ctx := context.Background()
+	a := create(t, s, subscription("Okko", "100", userA, date(2025, 1, 1), nil))
+	b := create(t, s, subscription("Kion", "100", userA, date(2025, 1, 1), nil))
+
+	changes := []model.PriceChange{
+		{SubscriptionId: b, EffectiveFrom: date(2025, 5, 1), Price: decimal.RequireFromString("130")},
+		{SubscriptionId: a, EffectiveFrom: date(2025, 6, 1), Price: decimal.RequireFromString("150")},
+		{SubscriptionId: a, EffectiveFrom: date(2025, 3, 1), Price: decimal.RequireFromString("120")},
+		{SubscriptionId: a, EffectiveFrom: date(2025, 6, 1), Price: decimal.RequireFromString("160.5")},
+	}
+	for _, change := range changes {
+		_, err := s.SetPrice(ctx, change, 0)
+		if err != nil {
+			t.Fatalf("set price: %v", err)
+		}
+	}
+
+	got, err := s.Prices(ctx, []int{b, a})
+	if err != nil {
+		t.Fatalf("prices: %v", err)
+	}
+	want := []model.PriceChange{changes[2], changes[3], changes[0]}
+	if len(got) != len(want) {
+		t.Fatalf("prices returned %d changes, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].SubscriptionId != want[i].SubscriptionId || !got[i].EffectiveFrom.Equal(want[i].EffectiveFrom) ||
+			!got[i].Price.Equal(want[i].Price) {
+			t.Errorf("price change %d = %d %s %s, want %d %s %s", i,
+				got[i].SubscriptionId, got[i].EffectiveFrom.Format(time.DateOnly), got[i].Price,
+				want[i].SubscriptionId, want[i].EffectiveFrom.Format(time.DateOnly), want[i].Price)
+		}
+	}
+
+	_, err = s.SetPrice(ctx, model.PriceChange{SubscriptionId: 1_000_000, EffectiveFrom: date(2025, 1, 1), Price: decimal.NewFromInt(1)}, 0)
+	assertError(t, "set price of a missing subscription", err, domain.ErrSubscriptionNotFound)
+}
+
+func testPriceVersion(t *testing.T, s interfaces.Storage) {
+	ctx := actor.WithActor(context.Background(), "alice")
+	id := create(t, s, subscription("Okko", "100", userA, date(2025, 1, 1), nil))
+	change := model.PriceChange{SubscriptionId: id, EffectiveFrom: date(2025, 4, 1), Price: decimal.RequireFromString("110.5")}
+
+	version, err := s.SetPrice(ctx, change, 1)
+	if err != nil {
+		t.Fatalf("set price: %v", err)
+	}
+	if version != 2 || load(t, s, id).Version != 2 {
+		t.Errorf("set price returned version %d, want 2 stored", version)
+	}
+
+	_, err = s.SetPrice(ctx, change, 1)
+	assertError(t, "set price of a stale version", err, domain.ErrVersionMismatch)
+
+	entries, err := s.History(ctx, id)
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("history has %d entries, want 2", len(entries))
+	}
+	entry := entries[1]
+	if entry.Action != model.ActionPrice || entry.Actor != "alice" || entry.Before == nil || entry.After == nil ||
+		entry.Before.Version != 1 || entry.After.Version != 2 {
+		t.Errorf("price history entry = %+v, want price by alice from version 1 to 2", entry)
+	}
+	if entry.PriceChange == nil || !entry.PriceChange.EffectiveFrom.Equal(change.EffectiveFrom) ||
+		!entry.PriceChange.Price.Equal(change.Price) {
+		t.Errorf("price history entry has change %+v, want %+v", entry.PriceChange, change)
+	}
+
+	err = s.Delete(ctx, id, 0)
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	_, err = s.SetPrice(ctx, change, 0)
+	assertError(t, "set price of a deleted subscription", err, domain.ErrSubscriptionNotFound)
+}
+
+func testRates(t *testing.T, s interfaces.Storage) {
+	ctx := context.Background()
+	err := s.SetRates(ctx, []model.ExchangeRate{
+		{Currency: "USD", Month: date(2025, 2, 1), Rate: decimal.RequireFromString("90.5")},
+		{Currency: "EUR", Month: date(2025, 1, 1), Rate: decimal.RequireFromString("100")},
+		{Currency: "USD", Month: date(2025, 1, 1), Rate: decimal.RequireFromString("91")},
+		{Currency: "USD", Month: date(2025, 3, 1), Rate: decimal.RequireFromString("89")},
+	})
+	if err != nil {
+		t.Fatalf("set rates: %v", err)
+	}
+	err = s.SetRates(ctx, []model.ExchangeRate{
+		{Currency: "USD", Month: date(2025, 1, 1), Rate: decimal.RequireFromString("92.123456")},
+	})
+	if err != nil {
+		t.Fatalf("set rates: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		currencies []string
+		to         time.Time
+		want       []string
+	}{
+		{"all", nil, date(2025, 12, 1), []string{"EUR 2025-01-01 100", "USD 2025-01-01 92.123456", "USD 2025-02-01 90.5", "USD 2025-03-01 89"}},
+		{"currency up to a month", []string{"USD"}, date(2025, 2, 1), []string{"USD 2025-01-01 92.123456", "USD 2025-02-01 90.5"}},
+		{"unknown currency", []string{"GBP"}, date(2025, 12, 1), nil},
+	}
+	for _, tt := range tests {
+		rates, err := s.Rates(ctx, tt.currencies, tt.to)
+		if err != nil {
+			t.Fatalf("rates %s: %v", tt.name, err)
+		}
+		var got []string
+		for _, r := range rates {
+			got = append(got, r.Currency+" "+r.Month.Format(time.DateOnly)+" "+r.Rate.String())
+		}
+		if !equal(got, tt.want) {
+			t.Errorf("rates %s = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
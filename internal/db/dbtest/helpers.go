@@ -0,0 +1,122 @@
+package dbtest
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/hollisgr/subservice/internal/dto"
+	"github.com/hollisgr/subservice/internal/interfaces"
+	"github.com/hollisgr/subservice/internal/model"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func itoa(id int) string {
+	return strconv.Itoa(id)
+}
+
+func subscription(name, price string, user uuid.UUID, start time.Time, end *time.Time) model.Subscription {
+	return model.Subscription{
+		ServiceName:   name,
+		Price:         decimal.RequireFromString(price),
+		UserId:        user,
+		StartDate:     start,
+		EndDate:       end,
+		BillingPeriod: model.PeriodMonthly,
+		Currency:      model.BaseCurrency,
+	}
+}
+
+func create(t *testing.T, s interfaces.Storage, sub model.Subscription) int {
+	t.Helper()
+	id, err := s.Create(context.Background(), sub)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	return id
+}
+
+func load(t *testing.T, s interfaces.Storage, id int) model.Subscription {
+	t.Helper()
+	sub, err := s.Load(context.Background(), id)
+	if err != nil {
+		t.Fatalf("load %d: %v", id, err)
+	}
+	return sub
+}
+
+func assertError(t *testing.T, op string, err, want error) {
+	t.Helper()
+	if !errors.Is(err, want) {
+		t.Errorf("%s returned %v, want %v", op, err, want)
+	}
+}
+
+func assertSubscription(t *testing.T, got, want model.Subscription) {
+	t.Helper()
+	endsEqual := got.EndDate == nil && want.EndDate == nil ||
+		got.EndDate != nil && want.EndDate != nil && got.EndDate.Equal(*want.EndDate)
+	if got.Id != want.Id || got.ServiceName != want.ServiceName || !got.Price.Equal(want.Price) ||
+		got.UserId != want.UserId || !got.StartDate.Equal(want.StartDate) || !endsEqual ||
+		got.BillingPeriod != want.BillingPeriod || got.Currency != want.Currency || got.Version != want.Version {
+		t.Errorf("got subscription %+v, want %+v", got, want)
+	}
+}
+
+func assertCount(t *testing.T, s interfaces.Storage, filter dto.LoadListRequestToDB, want int) {
+	t.Helper()
+	count, err := s.Count(context.Background(), filter)
+	if err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if count != want {
+		t.Errorf("count %+v = %d, want %d", filter, count, want)
+	}
+}
+
+func assertList(t *testing.T, s interfaces.Storage, name string, filter dto.LoadListRequestToDB, want []int) {
+	t.Helper()
+	subs, err := s.LoadList(context.Background(), filter)
+	if err != nil {
+		t.Fatalf("list %s: %v", name, err)
+	}
+	assertIds(t, "list "+name, subs, want)
+}
+
+func assertIds(t *testing.T, name string, subs []model.Subscription, want []int) {
+	t.Helper()
+	got := make([]int, 0, len(subs))
+	for _, sub := range subs {
+		got = append(got, sub.Id)
+	}
+	if len(got) != len(want) {
+		t.Errorf("%s returned ids %v, want %v", name, got, want)
+		return
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("%s returned ids %v, want %v", name, got, want)
+			return
+		}
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/hollisgr/subservice/internal/actor"
+	"github.com/hollisgr/subservice/internal/model"
+)
+
+// writeHistory appends an audit record of the change in the same transaction,
+// the actor is taken from ctx. Price is the scheduled change of a price action.
+func writeHistory(ctx context.Context, tx pgx.Tx, action string, id int, before, after *model.Subscription, price *model.PriceChange) error {
+	query := `
+		INSERT INTO
+			subscription_history
+			(
+				subscription_id,
+				action,
+				actor,
+				before,
+				after,
+				price_change
+			)
+		VALUES
+		(
+			@subscription_id,
+			@action,
+			@actor,
+			@before,
+			@after,
+			@price_change
+		)
+	`
+	args := pgx.NamedArgs{
+		"subscription_id": id,
+		"action":          action,
+		"actor":           actor.FromContext(ctx),
+		"before":          before,
+		"after":           after,
+		"price_change":    price,
+	}
+
+	_, err := tx.Exec(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("db write sub history exec error: %v", err)
+	}
+	return nil
+}
+
+func (d *db) History(ctx context.Context, id int) ([]model.HistoryEntry, error) {
+	var res []model.HistoryEntry
+	query := `
+		SELECT 
+			id,
+			subscription_id,
+			action,
+			actor,
+			changed_at,
+			before,
+			after,
+			price_change
+		FROM
+			subscription_history
+		WHERE
+			subscription_id = @id
+		ORDER BY 
+			changed_at,
+			id
+	`
+	args := pgx.NamedArgs{
+		"id": id,
+	}
+	rows, err := d.db.Query(ctx, query, args)
+	defer rows.Close()
+
+	if err != nil {
+		return res, fmt.Errorf("db load sub history query error: %v", err)
+	}
+
+	res, err = pgx.CollectRows(rows, pgx.RowToStructByName[model.HistoryEntry])
+
+	if err != nil {
+		return res, fmt.Errorf("db load sub history collect error: %v", err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,398 @@
+// Package memory implements interfaces.Storage in process memory for tests
+// and local demos. It follows the semantics of the Postgres storage and loses
+// all data on shutdown.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/hollisgr/subservice/internal/actor"
+	"github.com/hollisgr/subservice/internal/domain"
+	"github.com/hollisgr/subservice/internal/dto"
+	"github.com/hollisgr/subservice/internal/interfaces"
+	"github.com/hollisgr/subservice/internal/model"
+)
+
+// record is a stored subscription, a nil deletedAt means it is not deleted.
+type record struct {
+	sub       model.Subscription
+	deletedAt *time.Time
+}
+
+type rateKey struct {
+	currency string
+	month    time.Time
+}
+
+type memory struct {
+	mu sync.RWMutex
+
+	subs    map[int]*record
+	history []model.HistoryEntry
+	prices  map[int][]model.PriceChange
+	rates   map[rateKey]model.ExchangeRate
+
+	lastSubId     int
+	lastHistoryId int64
+	lastPriceId   int
+}
+
+func New() interfaces.Storage {
+	return &memory{
+		subs:   map[int]*record{},
+		prices: map[int][]model.PriceChange{},
+		rates:  map[rateKey]model.ExchangeRate{},
+	}
+}
+
+func (m *memory) Create(ctx context.Context, sub model.Subscription) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastSubId++
+	created := normalize(sub)
+	created.Id = m.lastSubId
+	created.Version = 1
+	m.subs[created.Id] = &record{sub: created}
+
+	m.writeHistory(ctx, model.ActionCreate, created.Id, nil, &created, nil)
+	return created.Id, nil
+}
+
+func (m *memory) Load(ctx context.Context, id int) (model.Subscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rec, ok := m.subs[id]
+	if !ok || rec.deletedAt != nil {
+		return model.Subscription{}, domain.ErrSubscriptionNotFound
+	}
+	return clone(rec.sub), nil
+}
+
+func (m *memory) LoadList(ctx context.Context, filter dto.LoadListRequestToDB) ([]model.Subscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sortKey := filter.Sort
+	if _, ok := comparators[sortKey]; !ok {
+		sortKey = "id"
+	}
+	compare := comparators[sortKey]
+	direction := 1
+	if filter.Order == "desc" {
+		direction = -1
+	}
+
+	var after *model.Subscription
+	if filter.After != nil {
+		cursor, err := cursorSubscription(sortKey, *filter.After)
+		if err != nil {
+			return []model.Subscription{}, fmt.Errorf("memory load sub list cursor error: %v", err)
+		}
+		after = &cursor
+	}
+
+	res := []model.Subscription{}
+	for _, rec := range m.subs {
+		if !matchList(rec, filter) {
+			continue
+		}
+		if after != nil && direction*compareWithId(compare, rec.sub, *after) <= 0 {
+			continue
+		}
+		res = append(res, clone(rec.sub))
+	}
+
+	slices.SortFunc(res, func(a, b model.Subscription) int {
+		return direction * compareWithId(compare, a, b)
+	})
+
+	return page(res, filter.Offset, filter.Limit), nil
+}
+
+func (m *memory) Count(ctx context.Context, filter dto.LoadListRequestToDB) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	count := 0
+	for _, rec := range m.subs {
+		if matchList(rec, filter) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// matchList reports whether the stored subscription passes the list filters.
+func matchList(rec *record, filter dto.LoadListRequestToDB) bool {
+	if rec.deletedAt != nil {
+		return false
+	}
+	sub := rec.sub
+
+	if filter.UserId != uuid.Nil && sub.UserId != filter.UserId {
+		return false
+	}
+	if filter.ServiceName != "" && sub.ServiceName != filter.ServiceName {
+		return false
+	}
+	if filter.ServicePrefix != "" &&
+		!strings.HasPrefix(strings.ToLower(sub.ServiceName), strings.ToLower(filter.ServicePrefix)) {
+		return false
+	}
+	if filter.PriceMin != nil && sub.Price.LessThan(*filter.PriceMin) {
+		return false
+	}
+	if filter.PriceMax != nil && sub.Price.GreaterThan(*filter.PriceMax) {
+		return false
+	}
+	if !filter.ActiveMonth.IsZero() {
+		// активна хотя бы один день месяца
+		month := dateOf(filter.ActiveMonth)
+		if !overlaps(sub, month, month.AddDate(0, 1, -1)) {
+			return false
+		}
+	}
+	return true
+}
+
+// page returns the rows left after skipping offset ones, at most limit of them.
+func page(subs []model.Subscription, offset, limit int) []model.Subscription {
+	if offset >= len(subs) {
+		return []model.Subscription{}
+	}
+	subs = subs[offset:]
+	if limit < len(subs) {
+		subs = subs[:limit]
+	}
+	return subs
+}
+
+// Update stores the subscription if its version still equals sub.Version,
+// a zero sub.Version skips the check. It returns the new version.
+func (m *memory) Update(ctx context.Context, sub model.Subscription) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec, err := m.lockForChange(sub.Id, sub.Version)
+	if err != nil {
+		return 0, err
+	}
+
+	before := clone(rec.sub)
+	after := normalize(sub)
+	after.Version = before.Version + 1
+	rec.sub = after
+
+	m.writeHistory(ctx, model.ActionUpdate, sub.Id, &before, &after, nil)
+	return after.Version, nil
+}
+
+// Delete soft-deletes the subscription if its version still equals the given one,
+// a zero version skips the check. The record is kept until Purge removes it.
+func (m *memory) Delete(ctx context.Context, id int, version int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec, err := m.lockForChange(id, version)
+	if err != nil {
+		return err
+	}
+
+	before := clone(rec.sub)
+	now := time.Now()
+	rec.deletedAt = &now
+	rec.sub.Version++
+
+	m.writeHistory(ctx, model.ActionDelete, id, &before, nil, nil)
+	return nil
+}
+
+// Restore brings back a soft-deleted subscription and returns its new version.
+func (m *memory) Restore(ctx context.Context, id int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec, ok := m.subs[id]
+	if !ok {
+		return 0, domain.ErrSubscriptionNotFound
+	}
+	if rec.deletedAt == nil {
+		return 0, domain.ErrNotDeleted
+	}
+
+	rec.deletedAt = nil
+	rec.sub.Version++
+	after := clone(rec.sub)
+
+	m.writeHistory(ctx, model.ActionRestore, id, nil, &after, nil)
+	return after.Version, nil
+}
+
+// Purge permanently removes subscriptions soft-deleted before the given time
+// together with their price changes and returns how many were removed.
+// Their history is kept.
+func (m *memory) Purge(ctx context.Context, before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var ids []int
+	for id, rec := range m.subs {
+		if rec.deletedAt != nil && rec.deletedAt.Before(before) {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	for _, id := range ids {
+		purged := clone(m.subs[id].sub)
+		delete(m.subs, id)
+		delete(m.prices, id)
+		m.writeHistory(ctx, model.ActionPurge, id, &purged, nil, nil)
+	}
+
+	return len(ids), nil
+}
+
+// lockForChange returns the stored subscription if it is still at the expected
+// version, a zero version skips the check. Soft-deleted subscriptions are
+// reported as not found. The caller must hold the write lock.
+func (m *memory) lockForChange(id int, version int) (*record, error) {
+	rec, ok := m.subs[id]
+	if !ok || rec.deletedAt != nil {
+		return nil, domain.ErrSubscriptionNotFound
+	}
+
+	if version != 0 && rec.sub.Version != version {
+		return nil, domain.ErrVersionMismatch
+	}
+
+	return rec, nil
+}
+
+func (m *memory) Cost(ctx context.Context, data dto.CostRequestToDB) ([]model.Subscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	start := dateOf(data.StartDate)
+	end := dateOf(data.EndDate)
+
+	res := []model.Subscription{}
+	for _, rec := range m.subs {
+		if rec.deletedAt != nil || !overlaps(rec.sub, start, end) {
+			continue
+		}
+		if data.ServiceName != "" && rec.sub.ServiceName != data.ServiceName {
+			continue
+		}
+		if data.UserId != uuid.Nil && rec.sub.UserId != data.UserId {
+			continue
+		}
+		res = append(res, clone(rec.sub))
+	}
+
+	slices.SortFunc(res, func(a, b model.Subscription) int {
+		if c := strings.Compare(a.ServiceName, b.ServiceName); c != 0 {
+			return c
+		}
+		if c := compareUUID(a, b); c != 0 {
+			return c
+		}
+		return compareWithId(compareStartDate, a, b)
+	})
+
+	return res, nil
+}
+
+// overlaps reports whether the subscription is active on at least one day
+// between start and end inclusive.
+func overlaps(sub model.Subscription, start, end time.Time) bool {
+	if sub.StartDate.After(end) {
+		return false
+	}
+	return sub.EndDate == nil || !sub.EndDate.Before(start)
+}
+
+// writeHistory appends an audit record of the change, the actor is taken
+// from ctx. Price is the scheduled change of a price action. The caller must
+// hold the write lock.
+func (m *memory) writeHistory(ctx context.Context, action string, id int, before, after *model.Subscription, price *model.PriceChange) {
+	m.lastHistoryId++
+	entry := model.HistoryEntry{
+		Id:             m.lastHistoryId,
+		SubscriptionId: id,
+		Action:         action,
+		Actor:          actor.FromContext(ctx),
+		ChangedAt:      time.Now(),
+	}
+	if before != nil {
+		snapshot := clone(*before)
+		entry.Before = &snapshot
+	}
+	if after != nil {
+		snapshot := clone(*after)
+		entry.After = &snapshot
+	}
+	if price != nil {
+		change := *price
+		entry.PriceChange = &change
+	}
+	m.history = append(m.history, entry)
+}
+
+func (m *memory) History(ctx context.Context, id int) ([]model.HistoryEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	res := []model.HistoryEntry{}
+	for _, entry := range m.history {
+		if entry.SubscriptionId != id {
+			continue
+		}
+		if entry.Before != nil {
+			before := clone(*entry.Before)
+			entry.Before = &before
+		}
+		if entry.After != nil {
+			after := clone(*entry.After)
+			entry.After = &after
+		}
+		res = append(res, entry)
+	}
+	return res, nil
+}
+
+// normalize rounds the stored values the way the database columns do:
+// dates lose their time of day and prices keep minor units only.
+func normalize(sub model.Subscription) model.Subscription {
+	sub = clone(sub)
+	sub.Price = sub.Price.Round(model.MinorUnits)
+	sub.StartDate = dateOf(sub.StartDate)
+	if sub.EndDate != nil {
+		end := dateOf(*sub.EndDate)
+		sub.EndDate = &end
+	}
+	return sub
+}
+
+// clone copies the subscription so the stored one can't be changed by callers.
+func clone(sub model.Subscription) model.Subscription {
+	if sub.EndDate != nil {
+		end := *sub.EndDate
+		sub.EndDate = &end
+	}
+	return sub
+}
+
+// dateOf returns the date of t at midnight UTC as a DATE column stores it.
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
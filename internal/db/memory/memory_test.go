@@ -0,0 +1,15 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/hollisgr/subservice/internal/db/dbtest"
+	"github.com/hollisgr/subservice/internal/db/memory"
+	"github.com/hollisgr/subservice/internal/interfaces"
+)
+
+func TestStorage(t *testing.T) {
+	dbtest.Run(t, func(t *testing.T) interfaces.Storage {
+		return memory.New()
+	})
+}
@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"github.com/hollisgr/subservice/internal/model"
+)
+
+// SetPrice schedules a price change if the subscription version still equals
+// the given one, a zero version skips the check. A change for the same month
+// is replaced. It returns the new subscription version.
+func (m *memory) SetPrice(ctx context.Context, change model.PriceChange, version int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec, err := m.lockForChange(change.SubscriptionId, version)
+	if err != nil {
+		return 0, err
+	}
+
+	change.EffectiveFrom = dateOf(change.EffectiveFrom)
+	change.Price = change.Price.Round(model.MinorUnits)
+	m.upsertPrice(&change)
+
+	before := clone(rec.sub)
+	rec.sub.Version++
+	after := clone(rec.sub)
+
+	m.writeHistory(ctx, model.ActionPrice, change.SubscriptionId, &before, &after, &change)
+	return after.Version, nil
+}
+
+// upsertPrice stores the change and sets its id, a change for the same month
+// is replaced. The caller must hold the write lock.
+func (m *memory) upsertPrice(change *model.PriceChange) {
+	changes := m.prices[change.SubscriptionId]
+	for i := range changes {
+		if changes[i].EffectiveFrom.Equal(change.EffectiveFrom) {
+			changes[i].Price = change.Price
+			change.Id = changes[i].Id
+			return
+		}
+	}
+
+	m.lastPriceId++
+	change.Id = m.lastPriceId
+	changes = append(changes, *change)
+	slices.SortFunc(changes, func(a, b model.PriceChange) int {
+		return a.EffectiveFrom.Compare(b.EffectiveFrom)
+	})
+	m.prices[change.SubscriptionId] = changes
+}
+
+// Prices returns the price changes of the given subscriptions ordered by
+// subscription and effective date.
+func (m *memory) Prices(ctx context.Context, ids []int) ([]model.PriceChange, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ids = slices.Clone(ids)
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	res := []model.PriceChange{}
+	for _, id := range ids {
+		res = append(res, m.prices[id]...)
+	}
+	return res, nil
+}
@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hollisgr/subservice/internal/model"
+)
+
+// SetRates stores the exchange rates at once, a rate for the same
+// currency and month is replaced.
+func (m *memory) SetRates(ctx context.Context, rates []model.ExchangeRate) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, rate := range rates {
+		rate.Month = dateOf(rate.Month)
+		rate.Rate = rate.Rate.Round(6)
+		m.rates[rateKey{currency: rate.Currency, month: rate.Month}] = rate
+	}
+	return nil
+}
+
+// Rates returns the exchange rates of the currencies set up to the given
+// month ordered by currency and month, no currencies means all of them.
+func (m *memory) Rates(ctx context.Context, currencies []string, to time.Time) ([]model.ExchangeRate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	res := []model.ExchangeRate{}
+	for _, rate := range m.rates {
+		if len(currencies) != 0 && !slices.Contains(currencies, rate.Currency) {
+			continue
+		}
+		if rate.Month.After(to) {
+			continue
+		}
+		res = append(res, rate)
+	}
+
+	slices.SortFunc(res, func(a, b model.ExchangeRate) int {
+		if c := strings.Compare(a.Currency, b.Currency); c != 0 {
+			return c
+		}
+		return a.Month.Compare(b.Month)
+	})
+	return res, nil
+}
@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"bytes"
+	"cmp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/hollisgr/subservice/internal/dto"
+	"github.com/hollisgr/subservice/internal/model"
+)
+
+type comparator func(a, b model.Subscription) int
+
+// comparators holds the allowed list sort keys,
+// open-ended subscriptions are sorted as ending last.
+var comparators = map[string]comparator{
+	"id": func(a, b model.Subscription) int {
+		return cmp.Compare(a.Id, b.Id)
+	},
+	"price": func(a, b model.Subscription) int {
+		return a.Price.Cmp(b.Price)
+	},
+	"start_date": compareStartDate,
+	"end_date": func(a, b model.Subscription) int {
+		switch {
+		case a.EndDate == nil && b.EndDate == nil:
+			return 0
+		case a.EndDate == nil:
+			return 1
+		case b.EndDate == nil:
+			return -1
+		}
+		return a.EndDate.Compare(*b.EndDate)
+	},
+	// регистр не учитываем, как и остальные хранилища
+	"service_name": func(a, b model.Subscription) int {
+		return strings.Compare(strings.ToLower(a.ServiceName), strings.ToLower(b.ServiceName))
+	},
+}
+
+func compareStartDate(a, b model.Subscription) int {
+	return a.StartDate.Compare(b.StartDate)
+}
+
+func compareUUID(a, b model.Subscription) int {
+	return bytes.Compare(a.UserId[:], b.UserId[:])
+}
+
+// compareWithId compares by the given order and then by id,
+// so that the order is total as the cursor expects.
+func compareWithId(compare comparator, a, b model.Subscription) int {
+	if c := compare(a, b); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Id, b.Id)
+}
+
+// cursorSubscription returns a subscription holding the cursor value in the
+// sort column and the cursor id, so it can be compared with the stored ones.
+func cursorSubscription(sortKey string, cursor dto.ListCursor) (model.Subscription, error) {
+	sub := model.Subscription{Id: cursor.Id}
+	var err error
+
+	switch sortKey {
+	case "price":
+		sub.Price, err = decimal.NewFromString(cursor.Value)
+	case "start_date":
+		sub.StartDate, err = time.Parse(time.DateOnly, cursor.Value)
+	case "end_date":
+		if cursor.Value != "infinity" {
+			var end time.Time
+			end, err = time.Parse(time.DateOnly, cursor.Value)
+			sub.EndDate = &end
+		}
+	case "service_name":
+		sub.ServiceName = cursor.Value
+	default:
+		// значение курсора по id совпадает с самим id
+		_, err = strconv.Atoi(cursor.Value)
+	}
+
+	return sub, err
+}
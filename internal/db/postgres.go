@@ -2,13 +2,19 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"main/internal/dto"
-	"main/internal/interfaces"
-	"main/internal/model"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/hollisgr/subservice/internal/domain"
+	"github.com/hollisgr/subservice/internal/dto"
+	"github.com/hollisgr/subservice/internal/interfaces"
+	"github.com/hollisgr/subservice/internal/model"
 )
 
 type db struct {
@@ -22,7 +28,12 @@ func New(pool *pgxpool.Pool) interfaces.Storage {
 }
 
 func (d *db) Create(ctx context.Context, sub model.Subscription) (int, error) {
-	id := 0
+	tx, err := d.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("db create sub begin tx err: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
 		INSERT INTO
 			subscriptions 
@@ -31,7 +42,9 @@ func (d *db) Create(ctx context.Context, sub model.Subscription) (int, error) {
 				price,
 				user_id,
 				start_date,
-				end_date
+				end_date,
+				billing_period,
+				currency
 			)
 		VALUES
 		(
@@ -39,24 +52,52 @@ func (d *db) Create(ctx context.Context, sub model.Subscription) (int, error) {
 			@price,
 			@user_id,
 			@start_date,
-			@end_date
+			@end_date,
+			@billing_period,
+			@currency
 		)
 		RETURNING
-			id
+			id,
+			service_name,
+			price,
+			user_id,
+			start_date,
+			end_date,
+			billing_period,
+			currency,
+			version
 	`
 	args := pgx.NamedArgs{
-		"service_name": sub.ServiceName,
-		"price":        sub.Price,
-		"user_id":      sub.UserId,
-		"start_date":   sub.StartDate,
-		"end_date":     sub.EndDate,
+		"service_name":   sub.ServiceName,
+		"price":          sub.Price,
+		"user_id":        sub.UserId,
+		"start_date":     sub.StartDate,
+		"end_date":       sub.EndDate,
+		"billing_period": sub.BillingPeriod,
+		"currency":       sub.Currency,
 	}
-	row := d.db.QueryRow(ctx, query, args)
-	err := row.Scan(&id)
+	rows, err := tx.Query(ctx, query, args)
+	defer rows.Close()
+
+	if err != nil {
+		return 0, fmt.Errorf("db create sub query err: %v", err)
+	}
+
+	created, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.Subscription])
+	if err != nil {
+		return 0, fmt.Errorf("db create sub collect row err: %v", err)
+	}
+
+	err = writeHistory(ctx, tx, model.ActionCreate, created.Id, nil, &created, nil)
 	if err != nil {
-		return id, fmt.Errorf("db create sub query err: %v", err)
+		return 0, err
 	}
-	return id, nil
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("db create sub commit err: %v", err)
+	}
+	return created.Id, nil
 }
 
 func (d *db) Load(ctx context.Context, id int) (model.Subscription, error) {
@@ -68,11 +109,16 @@ func (d *db) Load(ctx context.Context, id int) (model.Subscription, error) {
 			price,
 			user_id,
 			start_date,
-			end_date
+			end_date,
+			billing_period,
+			currency,
+			version
 		FROM
 			subscriptions
 		WHERE
 			id = @id
+			AND 
+				deleted_at IS NULL
 	`
 	args := pgx.NamedArgs{
 		"id": id,
@@ -87,8 +133,8 @@ func (d *db) Load(ctx context.Context, id int) (model.Subscription, error) {
 	res, err = pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.Subscription])
 
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return res, err
+		if errors.Is(err, pgx.ErrNoRows) {
+			return res, domain.ErrSubscriptionNotFound
 		}
 		return res, fmt.Errorf("db load sub collect row error: %v", err)
 	}
@@ -96,8 +142,54 @@ func (d *db) Load(ctx context.Context, id int) (model.Subscription, error) {
 	return res, nil
 }
 
-func (d *db) LoadList(ctx context.Context, limit int, offset int) ([]model.Subscription, error) {
+// sortColumns maps the allowed list sort keys to table columns,
+// open-ended subscriptions are sorted as ending last. Service names are
+// sorted case-insensitively in byte order, the same way in every storage.
+var sortColumns = map[string]string{
+	"id":           "id",
+	"price":        "price",
+	"start_date":   "start_date",
+	"end_date":     "COALESCE(end_date, 'infinity'::date)",
+	"service_name": `lower(service_name) COLLATE "C"`,
+}
+
+// sortValues holds the cursor value expressions compared with the sort columns.
+var sortValues = map[string]string{
+	"id":           "@cursor_value::integer",
+	"price":        "@cursor_value::numeric",
+	"start_date":   "@cursor_value::date",
+	"end_date":     "@cursor_value::date",
+	"service_name": `lower(@cursor_value::text) COLLATE "C"`,
+}
+
+func (d *db) LoadList(ctx context.Context, filter dto.LoadListRequestToDB) ([]model.Subscription, error) {
 	var res []model.Subscription
+
+	sortKey := filter.Sort
+	if _, ok := sortColumns[sortKey]; !ok {
+		sortKey = "id"
+	}
+	column := sortColumns[sortKey]
+	direction := "ASC"
+	if filter.Order == "desc" {
+		direction = "DESC"
+	}
+
+	conditions, args := listConditions(filter)
+	args["limit"] = filter.Limit
+	args["offset"] = filter.Offset
+
+	if filter.After != nil {
+		comparison := ">"
+		if direction == "DESC" {
+			comparison = "<"
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s (%s, @cursor_id)",
+			column, comparison, sortValues[sortKey]))
+		args["cursor_value"] = filter.After.Value
+		args["cursor_id"] = filter.After.Id
+	}
+
 	query := `
 		SELECT 
 			id,
@@ -105,20 +197,22 @@ func (d *db) LoadList(ctx context.Context, limit int, offset int) ([]model.Subsc
 			price,
 			user_id,
 			start_date,
-			end_date
+			end_date,
+			billing_period,
+			currency,
+			version
 		FROM
 			subscriptions
+		WHERE
+			` + strings.Join(conditions, " AND ") + `
 		ORDER BY 
-			id
+			` + column + ` ` + direction + `,
+			id ` + direction + `
 		LIMIT 
 			@limit
 		OFFSET 
 			@offset
 	`
-	args := pgx.NamedArgs{
-		"limit":  limit,
-		"offset": offset,
-	}
 	rows, err := d.db.Query(ctx, query, args)
 	defer rows.Close()
 
@@ -132,14 +226,87 @@ func (d *db) LoadList(ctx context.Context, limit int, offset int) ([]model.Subsc
 		return res, fmt.Errorf("db load sub list collect error: %v", err)
 	}
 
-	if len(res) == 0 {
-		return res, pgx.ErrNoRows
+	return res, nil
+}
+
+func (d *db) Count(ctx context.Context, filter dto.LoadListRequestToDB) (int, error) {
+	count := 0
+	conditions, args := listConditions(filter)
+	query := `
+		SELECT 
+			COUNT(*)
+		FROM
+			subscriptions
+		WHERE
+			` + strings.Join(conditions, " AND ") + `
+	`
+	row := d.db.QueryRow(ctx, query, args)
+	err := row.Scan(&count)
+	if err != nil {
+		return count, fmt.Errorf("db count sub query err: %v", err)
 	}
+	return count, nil
+}
 
-	return res, nil
+// listConditions builds the WHERE conditions and their arguments for the list filters.
+func listConditions(filter dto.LoadListRequestToDB) ([]string, pgx.NamedArgs) {
+	conditions := []string{"deleted_at IS NULL"}
+	args := pgx.NamedArgs{}
+
+	if filter.UserId != uuid.Nil {
+		conditions = append(conditions, "user_id = @user_id")
+		args["user_id"] = filter.UserId
+	}
+
+	if filter.ServiceName != "" {
+		conditions = append(conditions, "service_name = @service_name")
+		args["service_name"] = filter.ServiceName
+	}
+
+	if filter.ServicePrefix != "" {
+		conditions = append(conditions, "service_name ILIKE @service_prefix")
+		args["service_prefix"] = escapeLike(filter.ServicePrefix) + "%"
+	}
+
+	if filter.PriceMin != nil {
+		conditions = append(conditions, "price >= @price_min")
+		args["price_min"] = *filter.PriceMin
+	}
+
+	if filter.PriceMax != nil {
+		conditions = append(conditions, "price <= @price_max")
+		args["price_max"] = *filter.PriceMax
+	}
+
+	if !filter.ActiveMonth.IsZero() {
+		// активна хотя бы один день месяца
+		conditions = append(conditions, "start_date < @active_month_end AND (end_date IS NULL OR end_date >= @active_month)")
+		args["active_month"] = filter.ActiveMonth
+		args["active_month_end"] = filter.ActiveMonth.AddDate(0, 1, 0)
+	}
+
+	return conditions, args
+}
+
+// escapeLike escapes LIKE pattern metacharacters so the value is matched literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
 }
 
-func (d *db) Update(ctx context.Context, sub model.Subscription) error {
+// Update stores the subscription if its version still equals sub.Version,
+// a zero sub.Version skips the check. It returns the new version.
+func (d *db) Update(ctx context.Context, sub model.Subscription) (int, error) {
+	tx, err := d.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("db update sub begin tx error: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	before, err := lockForChange(ctx, tx, sub.Id, sub.Version)
+	if err != nil {
+		return 0, err
+	}
+
 	query := `
 		UPDATE
 			subscriptions
@@ -148,35 +315,79 @@ func (d *db) Update(ctx context.Context, sub model.Subscription) error {
 			price = @upd_price,
 			user_id = @upd_user_id,
 			start_date = @upd_start_date,
-			end_date = @upd_end_date
+			end_date = @upd_end_date,
+			billing_period = @upd_billing_period,
+			currency = @upd_currency,
+			version = version + 1
 		WHERE
 			id = @id
+		RETURNING
+			id,
+			service_name,
+			price,
+			user_id,
+			start_date,
+			end_date,
+			billing_period,
+			currency,
+			version
 	`
 	args := pgx.NamedArgs{
-		"upd_service_name": sub.ServiceName,
-		"upd_price":        sub.Price,
-		"upd_user_id":      sub.UserId,
-		"upd_start_date":   sub.StartDate,
-		"upd_end_date":     sub.EndDate,
-		"id":               sub.Id,
+		"upd_service_name":   sub.ServiceName,
+		"upd_price":          sub.Price,
+		"upd_user_id":        sub.UserId,
+		"upd_start_date":     sub.StartDate,
+		"upd_end_date":       sub.EndDate,
+		"upd_billing_period": sub.BillingPeriod,
+		"upd_currency":       sub.Currency,
+		"id":                 sub.Id,
 	}
 
-	result, err := d.db.Exec(ctx, query, args)
+	rows, err := tx.Query(ctx, query, args)
+	defer rows.Close()
+
 	if err != nil {
-		return fmt.Errorf("db update sub exec error: %v", err)
+		return 0, fmt.Errorf("db update sub query error: %v", err)
 	}
 
-	if result.RowsAffected() == 0 {
-		return pgx.ErrNoRows
+	after, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.Subscription])
+	if err != nil {
+		return 0, fmt.Errorf("db update sub collect row error: %v", err)
 	}
 
-	return nil
+	err = writeHistory(ctx, tx, model.ActionUpdate, sub.Id, &before, &after, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("db update sub commit error: %v", err)
+	}
+
+	return after.Version, nil
 }
 
-func (d *db) Delete(ctx context.Context, id int) error {
+// Delete soft-deletes the subscription if its version still equals the given one,
+// a zero version skips the check. The row is kept until Purge removes it.
+func (d *db) Delete(ctx context.Context, id int, version int) error {
+	tx, err := d.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("db delete sub begin tx error: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	before, err := lockForChange(ctx, tx, id, version)
+	if err != nil {
+		return err
+	}
+
 	query := `
-		DELETE FROM
+		UPDATE
 			subscriptions
+		SET
+			deleted_at = now(),
+			version = version + 1
 		WHERE
 			id = @id
 	`
@@ -184,20 +395,175 @@ func (d *db) Delete(ctx context.Context, id int) error {
 		"id": id,
 	}
 
-	result, err := d.db.Exec(ctx, query, args)
+	_, err = tx.Exec(ctx, query, args)
 	if err != nil {
 		return fmt.Errorf("db delete sub exec error: %v", err)
 	}
 
-	if result.RowsAffected() == 0 {
-		return pgx.ErrNoRows
+	err = writeHistory(ctx, tx, model.ActionDelete, id, &before, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("db delete sub commit error: %v", err)
 	}
 
 	return nil
 }
 
-func (d *db) Cost(ctx context.Context, data dto.CostRequestToDB) (model.Subscription, error) {
-	res := model.Subscription{}
+// Restore brings back a soft-deleted subscription and returns its new version.
+func (d *db) Restore(ctx context.Context, id int) (int, error) {
+	tx, err := d.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("db restore sub begin tx error: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE
+			subscriptions
+		SET
+			deleted_at = NULL,
+			version = version + 1
+		WHERE
+			id = @id
+			AND 
+				deleted_at IS NOT NULL
+		RETURNING
+			id,
+			service_name,
+			price,
+			user_id,
+			start_date,
+			end_date,
+			billing_period,
+			currency,
+			version
+	`
+	args := pgx.NamedArgs{
+		"id": id,
+	}
+
+	rows, err := tx.Query(ctx, query, args)
+	defer rows.Close()
+
+	if err != nil {
+		return 0, fmt.Errorf("db restore sub query error: %v", err)
+	}
+
+	after, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.Subscription])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, restoreMiss(ctx, tx, id)
+		}
+		return 0, fmt.Errorf("db restore sub collect row error: %v", err)
+	}
+
+	err = writeHistory(ctx, tx, model.ActionRestore, id, nil, &after, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("db restore sub commit error: %v", err)
+	}
+
+	return after.Version, nil
+}
+
+// restoreMiss tells apart a subscription that is not deleted from a missing
+// or purged one when restore found nothing to restore.
+func restoreMiss(ctx context.Context, tx pgx.Tx, id int) error {
+	query := `
+		SELECT EXISTS (
+			SELECT
+				1
+			FROM
+				subscriptions
+			WHERE
+				id = @id
+		)
+	`
+	args := pgx.NamedArgs{
+		"id": id,
+	}
+
+	var exists bool
+	err := tx.QueryRow(ctx, query, args).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("db restore sub exists query error: %v", err)
+	}
+
+	if exists {
+		return domain.ErrNotDeleted
+	}
+
+	return domain.ErrSubscriptionNotFound
+}
+
+// Purge permanently removes subscriptions soft-deleted before the given time
+// and returns how many were removed. Their history is kept.
+func (d *db) Purge(ctx context.Context, before time.Time) (int, error) {
+	tx, err := d.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("db purge sub begin tx error: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		DELETE FROM
+			subscriptions
+		WHERE
+			deleted_at < @before
+		RETURNING
+			id,
+			service_name,
+			price,
+			user_id,
+			start_date,
+			end_date,
+			billing_period,
+			currency,
+			version
+	`
+	args := pgx.NamedArgs{
+		"before": before,
+	}
+
+	rows, err := tx.Query(ctx, query, args)
+	defer rows.Close()
+
+	if err != nil {
+		return 0, fmt.Errorf("db purge sub query error: %v", err)
+	}
+
+	purged, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.Subscription])
+	if err != nil {
+		return 0, fmt.Errorf("db purge sub collect rows error: %v", err)
+	}
+
+	for _, sub := range purged {
+		err = writeHistory(ctx, tx, model.ActionPurge, sub.Id, &sub, nil, nil)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("db purge sub commit error: %v", err)
+	}
+
+	return len(purged), nil
+}
+
+// lockForChange loads the subscription for update inside the transaction and
+// checks it is still at the expected version, a zero version skips the check.
+// Soft-deleted subscriptions are reported as not found.
+func lockForChange(ctx context.Context, tx pgx.Tx, id int, version int) (model.Subscription, error) {
 	query := `
 		SELECT 
 			id,
@@ -205,25 +571,87 @@ func (d *db) Cost(ctx context.Context, data dto.CostRequestToDB) (model.Subscrip
 			price,
 			user_id,
 			start_date,
-			end_date
-		FROM 
+			end_date,
+			billing_period,
+			currency,
+			version
+		FROM
 			subscriptions
-		WHERE 
-			user_id = @user_id
-			AND 
-				service_name = @service_name
+		WHERE
+			id = @id
 			AND 
-				(@start_date BETWEEN start_date AND end_date 
-				OR 
-				@end_date BETWEEN start_date AND end_date)
+				deleted_at IS NULL
+		FOR UPDATE
 	`
 	args := pgx.NamedArgs{
-		"service_name": data.ServiceName,
-		"user_id":      data.UserId,
-		"start_date":   data.StartDate,
-		"end_date":     data.EndDate,
+		"id": id,
+	}
+	rows, err := tx.Query(ctx, query, args)
+	defer rows.Close()
+
+	if err != nil {
+		return model.Subscription{}, fmt.Errorf("db lock sub query error: %v", err)
+	}
+
+	res, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.Subscription])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return res, domain.ErrSubscriptionNotFound
+		}
+		return res, fmt.Errorf("db lock sub collect row error: %v", err)
+	}
+
+	if version != 0 && res.Version != version {
+		return res, domain.ErrVersionMismatch
+	}
+
+	return res, nil
+}
+
+func (d *db) Cost(ctx context.Context, data dto.CostRequestToDB) ([]model.Subscription, error) {
+	var res []model.Subscription
+	conditions := []string{
+		"deleted_at IS NULL",
+		"start_date <= @end_date",
+		"(end_date IS NULL OR end_date >= @start_date)",
+	}
+	args := pgx.NamedArgs{
+		"start_date": data.StartDate,
+		"end_date":   data.EndDate,
+	}
+
+	if data.ServiceName != "" {
+		conditions = append(conditions, "service_name = @service_name")
+		args["service_name"] = data.ServiceName
 	}
 
+	if data.UserId != uuid.Nil {
+		conditions = append(conditions, "user_id = @user_id")
+		args["user_id"] = data.UserId
+	}
+
+	query := `
+		SELECT 
+			id,
+			service_name,
+			price,
+			user_id,
+			start_date,
+			end_date,
+			billing_period,
+			currency,
+			version
+		FROM 
+			subscriptions
+		WHERE 
+			` + strings.Join(conditions, " AND ") + `
+		ORDER BY
+			service_name,
+			user_id,
+			start_date,
+			id
+	`
+
 	rows, err := d.db.Query(ctx, query, args)
 	defer rows.Close()
 
@@ -231,13 +659,10 @@ func (d *db) Cost(ctx context.Context, data dto.CostRequestToDB) (model.Subscrip
 		return res, fmt.Errorf("db cost sub query error: %v", err)
 	}
 
-	res, err = pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.Subscription])
+	res, err = pgx.CollectRows(rows, pgx.RowToStructByName[model.Subscription])
 
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return res, err
-		}
-		return res, fmt.Errorf("db cost sub collect row error: %v", err)
+		return res, fmt.Errorf("db cost sub collect rows error: %v", err)
 	}
 
 	return res, nil
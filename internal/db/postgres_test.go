@@ -0,0 +1,55 @@
+package db_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/hollisgr/subservice/internal/config"
+	"github.com/hollisgr/subservice/internal/db"
+	"github.com/hollisgr/subservice/internal/db/dbtest"
+	"github.com/hollisgr/subservice/internal/interfaces"
+	"github.com/hollisgr/subservice/internal/migrate"
+)
+
+// TestStorage runs the conformance suite against the database of TEST_PSQL_DSN,
+// its tables are emptied before every subtest.
+func TestStorage(t *testing.T) {
+	dsn := os.Getenv("TEST_PSQL_DSN")
+	if dsn == "" {
+		t.Skip("TEST_PSQL_DSN is not set")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer pool.Close()
+
+	conn := stdlib.OpenDBFromPool(pool)
+	defer conn.Close()
+	err = migrate.Run(ctx, config.DriverPostgres, conn, migrate.CommandUp, io.Discard)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	dbtest.Run(t, func(t *testing.T) interfaces.Storage {
+		_, err := pool.Exec(ctx, `
+			TRUNCATE
+				subscriptions,
+				subscription_history,
+				subscription_prices,
+				exchange_rates
+			RESTART IDENTITY CASCADE
+		`)
+		if err != nil {
+			t.Fatalf("truncate: %v", err)
+		}
+		return db.New(pool)
+	})
+}
@@ -0,0 +1,151 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/hollisgr/subservice/internal/model"
+)
+
+// SetPrice schedules a price change if the subscription version still equals
+// the given one, a zero version skips the check. A change for the same month
+// is replaced. It returns the new subscription version.
+func (d *db) SetPrice(ctx context.Context, change model.PriceChange, version int) (int, error) {
+	tx, err := d.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("db set sub price begin tx error: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	before, err := lockForChange(ctx, tx, change.SubscriptionId, version)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		INSERT INTO
+			subscription_prices
+			(
+				subscription_id,
+				effective_from,
+				price
+			)
+		VALUES
+		(
+			@subscription_id,
+			@effective_from,
+			@price
+		)
+		ON CONFLICT 
+			(subscription_id, effective_from)
+		DO UPDATE SET
+			price = EXCLUDED.price
+		RETURNING
+			id,
+			subscription_id,
+			effective_from,
+			price
+	`
+	args := pgx.NamedArgs{
+		"subscription_id": change.SubscriptionId,
+		"effective_from":  change.EffectiveFrom,
+		"price":           change.Price,
+	}
+
+	rows, err := tx.Query(ctx, query, args)
+	defer rows.Close()
+
+	if err != nil {
+		return 0, fmt.Errorf("db set sub price query error: %v", err)
+	}
+
+	saved, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.PriceChange])
+	if err != nil {
+		return 0, fmt.Errorf("db set sub price collect row error: %v", err)
+	}
+
+	query = `
+		UPDATE
+			subscriptions
+		SET
+			version = version + 1
+		WHERE
+			id = @id
+		RETURNING
+			id,
+			service_name,
+			price,
+			user_id,
+			start_date,
+			end_date,
+			billing_period,
+			currency,
+			version
+	`
+	args = pgx.NamedArgs{
+		"id": change.SubscriptionId,
+	}
+
+	rows, err = tx.Query(ctx, query, args)
+	defer rows.Close()
+
+	if err != nil {
+		return 0, fmt.Errorf("db set sub price version query error: %v", err)
+	}
+
+	after, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.Subscription])
+	if err != nil {
+		return 0, fmt.Errorf("db set sub price version collect row error: %v", err)
+	}
+
+	err = writeHistory(ctx, tx, model.ActionPrice, change.SubscriptionId, &before, &after, &saved)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("db set sub price commit error: %v", err)
+	}
+
+	return after.Version, nil
+}
+
+// Prices returns the price changes of the given subscriptions ordered by
+// subscription and effective date.
+func (d *db) Prices(ctx context.Context, ids []int) ([]model.PriceChange, error) {
+	var res []model.PriceChange
+	query := `
+		SELECT 
+			id,
+			subscription_id,
+			effective_from,
+			price
+		FROM
+			subscription_prices
+		WHERE
+			subscription_id = ANY(@ids)
+		ORDER BY 
+			subscription_id,
+			effective_from
+	`
+	args := pgx.NamedArgs{
+		"ids": ids,
+	}
+	rows, err := d.db.Query(ctx, query, args)
+	defer rows.Close()
+
+	if err != nil {
+		return res, fmt.Errorf("db load sub prices query error: %v", err)
+	}
+
+	res, err = pgx.CollectRows(rows, pgx.RowToStructByName[model.PriceChange])
+
+	if err != nil {
+		return res, fmt.Errorf("db load sub prices collect error: %v", err)
+	}
+
+	return res, nil
+}
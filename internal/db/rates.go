@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/hollisgr/subservice/internal/model"
+)
+
+// SetRates stores the exchange rates in one transaction, a rate for the same
+// currency and month is replaced.
+func (d *db) SetRates(ctx context.Context, rates []model.ExchangeRate) error {
+	tx, err := d.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("db set rates begin tx error: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO
+			exchange_rates
+			(
+				currency,
+				month,
+				rate
+			)
+		VALUES
+		(
+			@currency,
+			@month,
+			@rate
+		)
+		ON CONFLICT 
+			(currency, month)
+		DO UPDATE SET
+			rate = EXCLUDED.rate
+	`
+	batch := &pgx.Batch{}
+	for _, rate := range rates {
+		batch.Queue(query, pgx.NamedArgs{
+			"currency": rate.Currency,
+			"month":    rate.Month,
+			"rate":     rate.Rate,
+		})
+	}
+
+	err = tx.SendBatch(ctx, batch).Close()
+	if err != nil {
+		return fmt.Errorf("db set rates exec error: %v", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("db set rates commit error: %v", err)
+	}
+	return nil
+}
+
+// Rates returns the exchange rates of the currencies set up to the given
+// month ordered by currency and month, no currencies means all of them.
+func (d *db) Rates(ctx context.Context, currencies []string, to time.Time) ([]model.ExchangeRate, error) {
+	var res []model.ExchangeRate
+	query := `
+		SELECT 
+			currency,
+			month,
+			rate
+		FROM
+			exchange_rates
+		WHERE
+			(cardinality(@currencies::text[]) = 0 OR currency = ANY(@currencies))
+			AND 
+				month <= @to
+		ORDER BY 
+			currency,
+			month
+	`
+	if currencies == nil {
+		currencies = []string{}
+	}
+	args := pgx.NamedArgs{
+		"currencies": currencies,
+		"to":         to,
+	}
+	rows, err := d.db.Query(ctx, query, args)
+	defer rows.Close()
+
+	if err != nil {
+		return res, fmt.Errorf("db load rates query error: %v", err)
+	}
+
+	res, err = pgx.CollectRows(rows, pgx.RowToStructByName[model.ExchangeRate])
+
+	if err != nil {
+		return res, fmt.Errorf("db load rates collect error: %v", err)
+	}
+
+	return res, nil
+}
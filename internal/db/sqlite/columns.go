@@ -0,0 +1,156 @@
+package sqlite
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	modernc "modernc.org/sqlite"
+
+	"github.com/hollisgr/subservice/internal/model"
+)
+
+// SQLite has no date, uuid or numeric types, so dates are stored as
+// YYYY-MM-DD text, moments as fixed-width UTC text that sorts in time order
+// and prices as integer minor units.
+const (
+	dateLayout = time.DateOnly
+	timeLayout = "2006-01-02 15:04:05.000000"
+	endOfTime  = "9999-12-31"
+)
+
+const subscriptionColumns = `
+			id,
+			service_name,
+			price,
+			user_id,
+			start_date,
+			end_date,
+			billing_period,
+			currency,
+			version
+	`
+
+func init() {
+	// встроенный lower() в SQLite меняет регистр только у ASCII
+	modernc.MustRegisterDeterministicScalarFunction("unicode_lower", 1,
+		func(ctx *modernc.FunctionContext, args []driver.Value) (driver.Value, error) {
+			s, ok := args[0].(string)
+			if !ok {
+				return args[0], nil
+			}
+			return strings.ToLower(s), nil
+		})
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSubscription(row scanner) (model.Subscription, error) {
+	var (
+		sub    model.Subscription
+		price  int64
+		userId string
+		start  string
+		end    sql.NullString
+	)
+	err := row.Scan(&sub.Id, &sub.ServiceName, &price, &userId, &start, &end,
+		&sub.BillingPeriod, &sub.Currency, &sub.Version)
+	if err != nil {
+		return sub, err
+	}
+
+	sub.Price = fromMinor(price)
+	sub.UserId, err = uuid.Parse(userId)
+	if err != nil {
+		return sub, fmt.Errorf("user_id: %v", err)
+	}
+	sub.StartDate, err = time.Parse(dateLayout, start)
+	if err != nil {
+		return sub, fmt.Errorf("start_date: %v", err)
+	}
+	if end.Valid {
+		endDate, err := time.Parse(dateLayout, end.String)
+		if err != nil {
+			return sub, fmt.Errorf("end_date: %v", err)
+		}
+		sub.EndDate = &endDate
+	}
+	return sub, nil
+}
+
+func collectSubscriptions(rows *sql.Rows) ([]model.Subscription, error) {
+	res := []model.Subscription{}
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return res, err
+		}
+		res = append(res, sub)
+	}
+	return res, rows.Err()
+}
+
+// subscriptionArgs returns the named arguments of the stored subscription fields.
+func subscriptionArgs(sub model.Subscription) []any {
+	var end any
+	if sub.EndDate != nil {
+		end = formatDate(*sub.EndDate)
+	}
+	return []any{
+		sql.Named("service_name", sub.ServiceName),
+		sql.Named("price", toMinor(sub.Price)),
+		sql.Named("user_id", sub.UserId.String()),
+		sql.Named("start_date", formatDate(sub.StartDate)),
+		sql.Named("end_date", end),
+		sql.Named("billing_period", sub.BillingPeriod),
+		sql.Named("currency", sub.Currency),
+	}
+}
+
+// cursorValue converts the list cursor value to the stored form of the sort column.
+func cursorValue(sortKey string, value string) (any, error) {
+	switch sortKey {
+	case "price":
+		price, err := decimal.NewFromString(value)
+		if err != nil {
+			return nil, err
+		}
+		return toMinor(price), nil
+	case "start_date", "end_date":
+		if sortKey == "end_date" && value == "infinity" {
+			return endOfTime, nil
+		}
+		date, err := time.Parse(dateLayout, value)
+		if err != nil {
+			return nil, err
+		}
+		return formatDate(date), nil
+	case "service_name":
+		return strings.ToLower(value), nil
+	default:
+		return strconv.Atoi(value)
+	}
+}
+
+func toMinor(amount decimal.Decimal) int64 {
+	return amount.Shift(model.MinorUnits).Round(0).IntPart()
+}
+
+func fromMinor(amount int64) decimal.Decimal {
+	return decimal.New(amount, -model.MinorUnits)
+}
+
+func formatDate(t time.Time) string {
+	return t.Format(dateLayout)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
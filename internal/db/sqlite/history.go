@@ -0,0 +1,149 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hollisgr/subservice/internal/actor"
+	"github.com/hollisgr/subservice/internal/model"
+)
+
+// writeHistory appends an audit record of the change in the same transaction,
+// the actor is taken from ctx. Snapshots and the scheduled change of a price
+// action are stored as JSON.
+func writeHistory(ctx context.Context, tx *sql.Tx, action string, id int, before, after *model.Subscription, price *model.PriceChange) error {
+	query := `
+		INSERT INTO
+			subscription_history
+			(
+				subscription_id,
+				action,
+				actor,
+				changed_at,
+				before,
+				after,
+				price_change
+			)
+		VALUES
+		(
+			@subscription_id,
+			@action,
+			@actor,
+			@changed_at,
+			@before,
+			@after,
+			@price_change
+		)
+	`
+	beforeJSON, err := snapshot(before)
+	if err != nil {
+		return fmt.Errorf("sqlite write sub history marshal error: %v", err)
+	}
+	afterJSON, err := snapshot(after)
+	if err != nil {
+		return fmt.Errorf("sqlite write sub history marshal error: %v", err)
+	}
+	priceJSON, err := snapshot(price)
+	if err != nil {
+		return fmt.Errorf("sqlite write sub history marshal error: %v", err)
+	}
+
+	_, err = tx.ExecContext(ctx, query,
+		sql.Named("subscription_id", id),
+		sql.Named("action", action),
+		sql.Named("actor", actor.FromContext(ctx)),
+		sql.Named("changed_at", formatTime(time.Now())),
+		sql.Named("before", beforeJSON),
+		sql.Named("after", afterJSON),
+		sql.Named("price_change", priceJSON),
+	)
+	if err != nil {
+		return fmt.Errorf("sqlite write sub history exec error: %v", err)
+	}
+	return nil
+}
+
+// snapshot returns the value as JSON, a nil one is stored as NULL.
+func snapshot[T any](value *T) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (d *db) History(ctx context.Context, id int) ([]model.HistoryEntry, error) {
+	res := []model.HistoryEntry{}
+	query := `
+		SELECT
+			id,
+			subscription_id,
+			action,
+			actor,
+			changed_at,
+			before,
+			after,
+			price_change
+		FROM
+			subscription_history
+		WHERE
+			subscription_id = @id
+		ORDER BY
+			changed_at,
+			id
+	`
+	rows, err := d.db.QueryContext(ctx, query, sql.Named("id", id))
+	if err != nil {
+		return res, fmt.Errorf("sqlite load sub history query error: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			entry         model.HistoryEntry
+			changedAt     string
+			before, after sql.NullString
+			price         sql.NullString
+		)
+		err = rows.Scan(&entry.Id, &entry.SubscriptionId, &entry.Action, &entry.Actor, &changedAt, &before, &after, &price)
+		if err != nil {
+			return res, fmt.Errorf("sqlite load sub history scan error: %v", err)
+		}
+
+		entry.ChangedAt, err = time.Parse(timeLayout, changedAt)
+		if err != nil {
+			return res, fmt.Errorf("sqlite load sub history changed_at error: %v", err)
+		}
+		if before.Valid {
+			err = json.Unmarshal([]byte(before.String), &entry.Before)
+			if err != nil {
+				return res, fmt.Errorf("sqlite load sub history before error: %v", err)
+			}
+		}
+		if after.Valid {
+			err = json.Unmarshal([]byte(after.String), &entry.After)
+			if err != nil {
+				return res, fmt.Errorf("sqlite load sub history after error: %v", err)
+			}
+		}
+		if price.Valid {
+			err = json.Unmarshal([]byte(price.String), &entry.PriceChange)
+			if err != nil {
+				return res, fmt.Errorf("sqlite load sub history price_change error: %v", err)
+			}
+		}
+		res = append(res, entry)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return res, fmt.Errorf("sqlite load sub history collect error: %v", err)
+	}
+	return res, nil
+}
@@ -0,0 +1,155 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hollisgr/subservice/internal/model"
+)
+
+// SetPrice schedules a price change if the subscription version still equals
+// the given one, a zero version skips the check. A change for the same month
+// is replaced. It returns the new subscription version.
+func (d *db) SetPrice(ctx context.Context, change model.PriceChange, version int) (int, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("sqlite set sub price begin tx error: %v", err)
+	}
+	defer tx.Rollback()
+
+	before, err := lockForChange(ctx, tx, change.SubscriptionId, version)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		INSERT INTO
+			subscription_prices
+			(
+				subscription_id,
+				effective_from,
+				price
+			)
+		VALUES
+		(
+			@subscription_id,
+			@effective_from,
+			@price
+		)
+		ON CONFLICT
+			(subscription_id, effective_from)
+		DO UPDATE SET
+			price = excluded.price
+		RETURNING
+			id
+	`
+	saved := model.PriceChange{
+		SubscriptionId: change.SubscriptionId,
+		EffectiveFrom:  change.EffectiveFrom,
+		Price:          fromMinor(toMinor(change.Price)),
+	}
+	err = tx.QueryRowContext(ctx, query,
+		sql.Named("subscription_id", change.SubscriptionId),
+		sql.Named("effective_from", formatDate(change.EffectiveFrom)),
+		sql.Named("price", toMinor(change.Price)),
+	).Scan(&saved.Id)
+	if err != nil {
+		return 0, fmt.Errorf("sqlite set sub price query error: %v", err)
+	}
+
+	query = `
+		UPDATE
+			subscriptions
+		SET
+			version = version + 1
+		WHERE
+			id = @id
+		RETURNING
+			` + subscriptionColumns
+	after, err := scanSubscription(tx.QueryRowContext(ctx, query, sql.Named("id", change.SubscriptionId)))
+	if err != nil {
+		return 0, fmt.Errorf("sqlite set sub price version query error: %v", err)
+	}
+
+	err = writeHistory(ctx, tx, model.ActionPrice, change.SubscriptionId, &before, &after, &saved)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("sqlite set sub price commit error: %v", err)
+	}
+
+	return after.Version, nil
+}
+
+// Prices returns the price changes of the given subscriptions ordered by
+// subscription and effective date.
+func (d *db) Prices(ctx context.Context, ids []int) ([]model.PriceChange, error) {
+	res := []model.PriceChange{}
+	if len(ids) == 0 {
+		return res, nil
+	}
+
+	placeholders, args := inList("id", ids)
+	query := `
+		SELECT
+			id,
+			subscription_id,
+			effective_from,
+			price
+		FROM
+			subscription_prices
+		WHERE
+			subscription_id IN (` + placeholders + `)
+		ORDER BY
+			subscription_id,
+			effective_from
+	`
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return res, fmt.Errorf("sqlite load sub prices query error: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			change        model.PriceChange
+			effectiveFrom string
+			price         int64
+		)
+		err = rows.Scan(&change.Id, &change.SubscriptionId, &effectiveFrom, &price)
+		if err != nil {
+			return res, fmt.Errorf("sqlite load sub prices scan error: %v", err)
+		}
+		change.EffectiveFrom, err = time.Parse(dateLayout, effectiveFrom)
+		if err != nil {
+			return res, fmt.Errorf("sqlite load sub prices effective_from error: %v", err)
+		}
+		change.Price = fromMinor(price)
+		res = append(res, change)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return res, fmt.Errorf("sqlite load sub prices collect error: %v", err)
+	}
+	return res, nil
+}
+
+// inList returns the placeholders and named arguments of an IN list,
+// SQLite has no array parameters.
+func inList[T any](name string, values []T) (string, []any) {
+	placeholders := make([]string, len(values))
+	args := make([]any, len(values))
+	for i, v := range values {
+		arg := fmt.Sprintf("%s_%d", name, i)
+		placeholders[i] = "@" + arg
+		args[i] = sql.Named(arg, v)
+	}
+	return strings.Join(placeholders, ", "), args
+}
@@ -0,0 +1,127 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/hollisgr/subservice/internal/model"
+)
+
+// rateDecimals is the scale of the stored exchange rates.
+const rateDecimals = 6
+
+// SetRates stores the exchange rates in one transaction, a rate for the same
+// currency and month is replaced.
+func (d *db) SetRates(ctx context.Context, rates []model.ExchangeRate) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("sqlite set rates begin tx error: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO
+			exchange_rates
+			(
+				currency,
+				month,
+				rate
+			)
+		VALUES
+		(
+			@currency,
+			@month,
+			@rate
+		)
+		ON CONFLICT
+			(currency, month)
+		DO UPDATE SET
+			rate = excluded.rate
+	`
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("sqlite set rates prepare error: %v", err)
+	}
+	defer stmt.Close()
+
+	for _, rate := range rates {
+		_, err = stmt.ExecContext(ctx,
+			sql.Named("currency", rate.Currency),
+			sql.Named("month", formatDate(rate.Month)),
+			sql.Named("rate", rate.Rate.Round(rateDecimals).String()),
+		)
+		if err != nil {
+			return fmt.Errorf("sqlite set rates exec error: %v", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("sqlite set rates commit error: %v", err)
+	}
+	return nil
+}
+
+// Rates returns the exchange rates of the currencies set up to the given
+// month ordered by currency and month, no currencies means all of them.
+func (d *db) Rates(ctx context.Context, currencies []string, to time.Time) ([]model.ExchangeRate, error) {
+	res := []model.ExchangeRate{}
+	condition := "1 = 1"
+	args := []any{sql.Named("to", formatDate(to))}
+	if len(currencies) != 0 {
+		placeholders, currencyArgs := inList("currency", currencies)
+		condition = "currency IN (" + placeholders + ")"
+		args = append(args, currencyArgs...)
+	}
+
+	query := `
+		SELECT
+			currency,
+			month,
+			rate
+		FROM
+			exchange_rates
+		WHERE
+			` + condition + `
+			AND
+				month <= @to
+		ORDER BY
+			currency,
+			month
+	`
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return res, fmt.Errorf("sqlite load rates query error: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			rate        model.ExchangeRate
+			month, text string
+		)
+		err = rows.Scan(&rate.Currency, &month, &text)
+		if err != nil {
+			return res, fmt.Errorf("sqlite load rates scan error: %v", err)
+		}
+		rate.Month, err = time.Parse(dateLayout, month)
+		if err != nil {
+			return res, fmt.Errorf("sqlite load rates month error: %v", err)
+		}
+		rate.Rate, err = decimal.NewFromString(text)
+		if err != nil {
+			return res, fmt.Errorf("sqlite load rates rate error: %v", err)
+		}
+		res = append(res, rate)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return res, fmt.Errorf("sqlite load rates collect error: %v", err)
+	}
+	return res, nil
+}
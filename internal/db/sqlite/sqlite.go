@@ -0,0 +1,518 @@
+// Package sqlite implements interfaces.Storage on SQLite for single-node
+// deployments, its schema is kept in migrations/sqlite.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/hollisgr/subservice/internal/domain"
+	"github.com/hollisgr/subservice/internal/dto"
+	"github.com/hollisgr/subservice/internal/interfaces"
+	"github.com/hollisgr/subservice/internal/model"
+)
+
+type db struct {
+	db *sql.DB
+}
+
+// New returns the storage on a database opened with pkg/sqlite.Open,
+// which enforces the foreign keys the storage relies on.
+func New(conn *sql.DB) interfaces.Storage {
+	return &db{
+		db: conn,
+	}
+}
+
+func (d *db) Create(ctx context.Context, sub model.Subscription) (int, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("sqlite create sub begin tx err: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO
+			subscriptions
+			(
+				service_name,
+				price,
+				user_id,
+				start_date,
+				end_date,
+				billing_period,
+				currency
+			)
+		VALUES
+		(
+			@service_name,
+			@price,
+			@user_id,
+			@start_date,
+			@end_date,
+			@billing_period,
+			@currency
+		)
+		RETURNING
+			` + subscriptionColumns
+
+	created, err := scanSubscription(tx.QueryRowContext(ctx, query, subscriptionArgs(sub)...))
+	if err != nil {
+		return 0, fmt.Errorf("sqlite create sub query err: %v", err)
+	}
+
+	err = writeHistory(ctx, tx, model.ActionCreate, created.Id, nil, &created, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("sqlite create sub commit err: %v", err)
+	}
+	return created.Id, nil
+}
+
+func (d *db) Load(ctx context.Context, id int) (model.Subscription, error) {
+	query := `
+		SELECT
+			` + subscriptionColumns + `
+		FROM
+			subscriptions
+		WHERE
+			id = @id
+			AND
+				deleted_at IS NULL
+	`
+	res, err := scanSubscription(d.db.QueryRowContext(ctx, query, sql.Named("id", id)))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return res, domain.ErrSubscriptionNotFound
+		}
+		return res, fmt.Errorf("sqlite load sub query error: %v", err)
+	}
+
+	return res, nil
+}
+
+// sortColumns maps the allowed list sort keys to table columns,
+// open-ended subscriptions are sorted as ending last. Service names are
+// sorted case-insensitively in byte order, the same way in every storage.
+var sortColumns = map[string]string{
+	"id":           "id",
+	"price":        "price",
+	"start_date":   "start_date",
+	"end_date":     "COALESCE(end_date, '" + endOfTime + "')",
+	"service_name": "unicode_lower(service_name)",
+}
+
+func (d *db) LoadList(ctx context.Context, filter dto.LoadListRequestToDB) ([]model.Subscription, error) {
+	var res []model.Subscription
+
+	sortKey := filter.Sort
+	if _, ok := sortColumns[sortKey]; !ok {
+		sortKey = "id"
+	}
+	column := sortColumns[sortKey]
+	direction := "ASC"
+	if filter.Order == "desc" {
+		direction = "DESC"
+	}
+
+	conditions, args := listConditions(filter)
+	args = append(args, sql.Named("limit", filter.Limit), sql.Named("offset", filter.Offset))
+
+	if filter.After != nil {
+		comparison := ">"
+		if direction == "DESC" {
+			comparison = "<"
+		}
+		value, err := cursorValue(sortKey, filter.After.Value)
+		if err != nil {
+			return res, fmt.Errorf("sqlite load sub list cursor error: %v", err)
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s (@cursor_value, @cursor_id)", column, comparison))
+		args = append(args, sql.Named("cursor_value", value), sql.Named("cursor_id", filter.After.Id))
+	}
+
+	query := `
+		SELECT
+			` + subscriptionColumns + `
+		FROM
+			subscriptions
+		WHERE
+			` + strings.Join(conditions, " AND ") + `
+		ORDER BY
+			` + column + ` ` + direction + `,
+			id ` + direction + `
+		LIMIT
+			@limit
+		OFFSET
+			@offset
+	`
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return res, fmt.Errorf("sqlite load sub list query error: %v", err)
+	}
+	defer rows.Close()
+
+	res, err = collectSubscriptions(rows)
+	if err != nil {
+		return res, fmt.Errorf("sqlite load sub list collect error: %v", err)
+	}
+
+	return res, nil
+}
+
+func (d *db) Count(ctx context.Context, filter dto.LoadListRequestToDB) (int, error) {
+	count := 0
+	conditions, args := listConditions(filter)
+	query := `
+		SELECT
+			COUNT(*)
+		FROM
+			subscriptions
+		WHERE
+			` + strings.Join(conditions, " AND ") + `
+	`
+	err := d.db.QueryRowContext(ctx, query, args...).Scan(&count)
+	if err != nil {
+		return count, fmt.Errorf("sqlite count sub query err: %v", err)
+	}
+	return count, nil
+}
+
+// listConditions builds the WHERE conditions and their arguments for the list filters.
+func listConditions(filter dto.LoadListRequestToDB) ([]string, []any) {
+	conditions := []string{"deleted_at IS NULL"}
+	args := []any{}
+
+	if filter.UserId != uuid.Nil {
+		conditions = append(conditions, "user_id = @user_id")
+		args = append(args, sql.Named("user_id", filter.UserId.String()))
+	}
+
+	if filter.ServiceName != "" {
+		conditions = append(conditions, "service_name = @service_name")
+		args = append(args, sql.Named("service_name", filter.ServiceName))
+	}
+
+	if filter.ServicePrefix != "" {
+		// LIKE в SQLite не различает регистр только для ASCII
+		conditions = append(conditions, `unicode_lower(service_name) LIKE @service_prefix ESCAPE '\'`)
+		args = append(args, sql.Named("service_prefix", escapeLike(strings.ToLower(filter.ServicePrefix))+"%"))
+	}
+
+	if filter.PriceMin != nil {
+		conditions = append(conditions, "price >= @price_min")
+		args = append(args, sql.Named("price_min", toMinor(*filter.PriceMin)))
+	}
+
+	if filter.PriceMax != nil {
+		conditions = append(conditions, "price <= @price_max")
+		args = append(args, sql.Named("price_max", toMinor(*filter.PriceMax)))
+	}
+
+	if !filter.ActiveMonth.IsZero() {
+		// активна хотя бы один день месяца
+		conditions = append(conditions, "start_date < @active_month_end AND (end_date IS NULL OR end_date >= @active_month)")
+		args = append(args,
+			sql.Named("active_month", formatDate(filter.ActiveMonth)),
+			sql.Named("active_month_end", formatDate(filter.ActiveMonth.AddDate(0, 1, 0))))
+	}
+
+	return conditions, args
+}
+
+// escapeLike escapes LIKE pattern metacharacters so the value is matched literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// Update stores the subscription if its version still equals sub.Version,
+// a zero sub.Version skips the check. It returns the new version.
+func (d *db) Update(ctx context.Context, sub model.Subscription) (int, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("sqlite update sub begin tx error: %v", err)
+	}
+	defer tx.Rollback()
+
+	before, err := lockForChange(ctx, tx, sub.Id, sub.Version)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		UPDATE
+			subscriptions
+		SET
+			service_name = @service_name,
+			price = @price,
+			user_id = @user_id,
+			start_date = @start_date,
+			end_date = @end_date,
+			billing_period = @billing_period,
+			currency = @currency,
+			version = version + 1
+		WHERE
+			id = @id
+		RETURNING
+			` + subscriptionColumns
+	args := append(subscriptionArgs(sub), sql.Named("id", sub.Id))
+
+	after, err := scanSubscription(tx.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		return 0, fmt.Errorf("sqlite update sub query error: %v", err)
+	}
+
+	err = writeHistory(ctx, tx, model.ActionUpdate, sub.Id, &before, &after, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("sqlite update sub commit error: %v", err)
+	}
+
+	return after.Version, nil
+}
+
+// Delete soft-deletes the subscription if its version still equals the given one,
+// a zero version skips the check. The row is kept until Purge removes it.
+func (d *db) Delete(ctx context.Context, id int, version int) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("sqlite delete sub begin tx error: %v", err)
+	}
+	defer tx.Rollback()
+
+	before, err := lockForChange(ctx, tx, id, version)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE
+			subscriptions
+		SET
+			deleted_at = @now,
+			version = version + 1
+		WHERE
+			id = @id
+	`
+	_, err = tx.ExecContext(ctx, query, sql.Named("now", formatTime(time.Now())), sql.Named("id", id))
+	if err != nil {
+		return fmt.Errorf("sqlite delete sub exec error: %v", err)
+	}
+
+	err = writeHistory(ctx, tx, model.ActionDelete, id, &before, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("sqlite delete sub commit error: %v", err)
+	}
+
+	return nil
+}
+
+// Restore brings back a soft-deleted subscription and returns its new version.
+func (d *db) Restore(ctx context.Context, id int) (int, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("sqlite restore sub begin tx error: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE
+			subscriptions
+		SET
+			deleted_at = NULL,
+			version = version + 1
+		WHERE
+			id = @id
+			AND
+				deleted_at IS NOT NULL
+		RETURNING
+			` + subscriptionColumns
+
+	after, err := scanSubscription(tx.QueryRowContext(ctx, query, sql.Named("id", id)))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, restoreMiss(ctx, tx, id)
+		}
+		return 0, fmt.Errorf("sqlite restore sub query error: %v", err)
+	}
+
+	err = writeHistory(ctx, tx, model.ActionRestore, id, nil, &after, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("sqlite restore sub commit error: %v", err)
+	}
+
+	return after.Version, nil
+}
+
+// restoreMiss tells apart a subscription that is not deleted from a missing
+// or purged one when restore found nothing to restore.
+func restoreMiss(ctx context.Context, tx *sql.Tx, id int) error {
+	query := `
+		SELECT EXISTS (
+			SELECT
+				1
+			FROM
+				subscriptions
+			WHERE
+				id = @id
+		)
+	`
+
+	var exists bool
+	err := tx.QueryRowContext(ctx, query, sql.Named("id", id)).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("sqlite restore sub exists query error: %v", err)
+	}
+
+	if exists {
+		return domain.ErrNotDeleted
+	}
+
+	return domain.ErrSubscriptionNotFound
+}
+
+// Purge permanently removes subscriptions soft-deleted before the given time
+// and returns how many were removed. Their history is kept.
+func (d *db) Purge(ctx context.Context, before time.Time) (int, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("sqlite purge sub begin tx error: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		DELETE FROM
+			subscriptions
+		WHERE
+			deleted_at < @before
+		RETURNING
+			` + subscriptionColumns
+
+	rows, err := tx.QueryContext(ctx, query, sql.Named("before", formatTime(before)))
+	if err != nil {
+		return 0, fmt.Errorf("sqlite purge sub query error: %v", err)
+	}
+
+	purged, err := collectSubscriptions(rows)
+	rows.Close()
+	if err != nil {
+		return 0, fmt.Errorf("sqlite purge sub collect rows error: %v", err)
+	}
+
+	for _, sub := range purged {
+		err = writeHistory(ctx, tx, model.ActionPurge, sub.Id, &sub, nil, nil)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("sqlite purge sub commit error: %v", err)
+	}
+
+	return len(purged), nil
+}
+
+// lockForChange loads the subscription inside the transaction, which holds
+// the database write lock, and checks it is still at the expected version,
+// a zero version skips the check. Soft-deleted subscriptions are reported as not found.
+func lockForChange(ctx context.Context, tx *sql.Tx, id int, version int) (model.Subscription, error) {
+	query := `
+		SELECT
+			` + subscriptionColumns + `
+		FROM
+			subscriptions
+		WHERE
+			id = @id
+			AND
+				deleted_at IS NULL
+	`
+	res, err := scanSubscription(tx.QueryRowContext(ctx, query, sql.Named("id", id)))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return res, domain.ErrSubscriptionNotFound
+		}
+		return res, fmt.Errorf("sqlite lock sub query error: %v", err)
+	}
+
+	if version != 0 && res.Version != version {
+		return res, domain.ErrVersionMismatch
+	}
+
+	return res, nil
+}
+
+func (d *db) Cost(ctx context.Context, data dto.CostRequestToDB) ([]model.Subscription, error) {
+	var res []model.Subscription
+	conditions := []string{
+		"deleted_at IS NULL",
+		"start_date <= @end_date",
+		"(end_date IS NULL OR end_date >= @start_date)",
+	}
+	args := []any{
+		sql.Named("start_date", formatDate(data.StartDate)),
+		sql.Named("end_date", formatDate(data.EndDate)),
+	}
+
+	if data.ServiceName != "" {
+		conditions = append(conditions, "service_name = @service_name")
+		args = append(args, sql.Named("service_name", data.ServiceName))
+	}
+
+	if data.UserId != uuid.Nil {
+		conditions = append(conditions, "user_id = @user_id")
+		args = append(args, sql.Named("user_id", data.UserId.String()))
+	}
+
+	query := `
+		SELECT
+			` + subscriptionColumns + `
+		FROM
+			subscriptions
+		WHERE
+			` + strings.Join(conditions, " AND ") + `
+		ORDER BY
+			service_name,
+			user_id,
+			start_date,
+			id
+	`
+
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return res, fmt.Errorf("sqlite cost sub query error: %v", err)
+	}
+	defer rows.Close()
+
+	res, err = collectSubscriptions(rows)
+	if err != nil {
+		return res, fmt.Errorf("sqlite cost sub collect rows error: %v", err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,36 @@
+package sqlite_test
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/hollisgr/subservice/internal/config"
+	"github.com/hollisgr/subservice/internal/db/dbtest"
+	"github.com/hollisgr/subservice/internal/db/sqlite"
+	"github.com/hollisgr/subservice/internal/interfaces"
+	"github.com/hollisgr/subservice/internal/migrate"
+	sqliteconn "github.com/hollisgr/subservice/pkg/sqlite"
+)
+
+// TestStorage runs the conformance suite against a new database file
+// migrated by the embedded SQLite migrations for every subtest.
+func TestStorage(t *testing.T) {
+	dbtest.Run(t, func(t *testing.T) interfaces.Storage {
+		ctx := context.Background()
+		conn, err := sqliteconn.Open(ctx, filepath.Join(t.TempDir(), "subservice.db"))
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		t.Cleanup(func() {
+			conn.Close()
+		})
+
+		err = migrate.Run(ctx, config.DriverSQLite, conn, migrate.CommandUp, io.Discard)
+		if err != nil {
+			t.Fatalf("migrate: %v", err)
+		}
+		return sqlite.New(conn)
+	})
+}
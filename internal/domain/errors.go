@@ -0,0 +1,64 @@
+package domain
+
+import "errors"
+
+// Kinds of domain errors, errors returned by storage and services wrap one
+// of them so callers can tell them apart with errors.Is.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrValidation   = errors.New("validation failed")
+	ErrConflict     = errors.New("conflict")
+	ErrPrecondition = errors.New("precondition failed")
+	// ErrUnprocessable is a valid request the service lacks data to serve.
+	ErrUnprocessable = errors.New("unprocessable")
+)
+
+// Error is a domain error of a kind. Field and Code name the invalid request
+// field and the failed rule of a validation error, both may be empty.
+type Error struct {
+	Kind    error
+	Field   string
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+func NotFound(msg string) error {
+	return &Error{Kind: ErrNotFound, Message: msg}
+}
+
+// Validation returns an error of an invalid request field.
+func Validation(field, code, msg string) error {
+	return &Error{Kind: ErrValidation, Field: field, Code: code, Message: msg}
+}
+
+func Conflict(msg string) error {
+	return &Error{Kind: ErrConflict, Message: msg}
+}
+
+func Precondition(msg string) error {
+	return &Error{Kind: ErrPrecondition, Message: msg}
+}
+
+func Unprocessable(msg string) error {
+	return &Error{Kind: ErrUnprocessable, Message: msg}
+}
+
+var (
+	// ErrSubscriptionNotFound is returned by storage when there is no such subscription.
+	ErrSubscriptionNotFound = NotFound("subscription not found")
+
+	// ErrVersionMismatch is returned by storage when a subscription was changed
+	// since the version the caller based its modification on.
+	ErrVersionMismatch = Precondition("subscription was modified, reload it and retry")
+
+	// ErrNotDeleted is returned by storage when restoring a subscription that is not deleted.
+	ErrNotDeleted = Conflict("subscription is not deleted")
+)
@@ -1,22 +1,68 @@
 package dto
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 type CreateSubRequest struct {
-	ServiceName string    `json:"service_name" example:"Yandex Plus"`
-	Price       uint      `json:"price" example:"400"`
-	UserId      uuid.UUID `json:"user_id" example:"dceb1963-e152-47ff-a562-81a360627309"`
-	StartDate   string    `json:"start_date" example:"01-2025"`
-	EndDate     string    `json:"end_date,omitempty" example:"02-2025"`
+	ServiceName   string           `json:"service_name" example:"Yandex Plus" binding:"required,max=255"`
+	Price         *decimal.Decimal `json:"price" swaggertype:"string" example:"399.99" binding:"required,money"`
+	UserId        uuid.UUID        `json:"user_id" example:"dceb1963-e152-47ff-a562-81a360627309" binding:"required"`
+	StartDate     string           `json:"start_date" example:"17-03-2025" binding:"required,date"`
+	EndDate       string           `json:"end_date,omitempty" example:"16-09-2025" binding:"omitempty,date"`
+	BillingPeriod string           `json:"billing_period,omitempty" example:"monthly" binding:"omitempty,oneof=weekly monthly quarterly annual"`
+	Currency      string           `json:"currency,omitempty" example:"RUB" binding:"omitempty,currency"`
 }
 
 type LoadListRequest struct {
-	Limit  uint `json:"limit" example:"10"`
-	Offset uint `json:"offset" example:"1"`
+	Limit         int              `json:"limit" example:"10"`
+	Offset        int              `json:"offset" example:"1"`
+	UserId        uuid.UUID        `json:"user_id,omitempty" example:"dceb1963-e152-47ff-a562-81a360627309"`
+	ServiceName   string           `json:"service_name,omitempty" example:"Yandex Plus"`
+	ServicePrefix string           `json:"service_prefix,omitempty" example:"yandex"`
+	PriceMin      *decimal.Decimal `json:"price_min,omitempty" swaggertype:"string" example:"100"`
+	PriceMax      *decimal.Decimal `json:"price_max,omitempty" swaggertype:"string" example:"500"`
+	ActiveMonth   string           `json:"active_month,omitempty" example:"01-2025"`
+	Sort          string           `json:"sort,omitempty" example:"price"`
+	Order         string           `json:"order,omitempty" example:"desc"`
+	Cursor        string           `json:"cursor,omitempty" example:"eyJzIjoiIiwibyI6IiIsInYiOiIxMCIsImlkIjoxMH0"`
+}
+
+type LoadListResponce struct {
+	Items      []LoadSubResponce `json:"items"`
+	Total      int               `json:"total" example:"42"`
+	Limit      int               `json:"limit" example:"10"`
+	Offset     int               `json:"offset" example:"0"`
+	NextCursor string            `json:"next_cursor,omitempty" example:"eyJzIjoiIiwibyI6IiIsInYiOiIxMCIsImlkIjoxMH0"`
+}
+
+// ListCursor is the sort column value and id of the last row of the previous page.
+type ListCursor struct {
+	Value string
+	Id    int
+}
+
+// LoadListRequestToDB holds the list query filters, zero values
+// (empty strings, uuid.Nil, nil prices, zero time) mean the filter is not applied.
+type LoadListRequestToDB struct {
+	Limit         int
+	Offset        int
+	UserId        uuid.UUID
+	ServiceName   string
+	ServicePrefix string
+	PriceMin      *decimal.Decimal
+	PriceMax      *decimal.Decimal
+	ActiveMonth   time.Time
+	Sort          string
+	Order         string
+	After         *ListCursor
 }
 
 type CreateSubResponce struct {
@@ -32,31 +78,130 @@ type DeleteSubResponce struct {
 	Success bool `json:"success" example:"true"`
 }
 
+// LoadSubResponce is a subscription, Price is its price before the scheduled
+// price changes and CurrentPrice the price in effect today.
 type LoadSubResponce struct {
-	Id          int       `json:"id" example:"1"`
-	ServiceName string    `json:"service_name" example:"Yandex Plus"`
-	Price       uint      `json:"price" example:"400"`
-	UserId      uuid.UUID `json:"user_id" example:"dceb1963-e152-47ff-a562-81a360627309"`
-	StartDate   string    `json:"start_date" example:"01-2025"`
-	EndDate     string    `json:"end_date,omitempty" example:"02-2025"`
+	Id            int              `json:"id" example:"1"`
+	ServiceName   string           `json:"service_name" example:"Yandex Plus"`
+	Price         decimal.Decimal  `json:"price" swaggertype:"string" example:"399.99"`
+	CurrentPrice  *decimal.Decimal `json:"current_price,omitempty" swaggertype:"string" example:"499.99"`
+	UserId        uuid.UUID        `json:"user_id" example:"dceb1963-e152-47ff-a562-81a360627309"`
+	StartDate     string           `json:"start_date" example:"17-03-2025"`
+	EndDate       string           `json:"end_date,omitempty" example:"16-09-2025"`
+	BillingPeriod string           `json:"billing_period,omitempty" example:"monthly"`
+	Currency      string           `json:"currency,omitempty" example:"RUB"`
+	Version       int              `json:"version" example:"1"`
+}
+
+type HistoryResponce struct {
+	Id          int64                `json:"id" example:"1"`
+	Action      string               `json:"action" example:"update"`
+	Actor       string               `json:"actor" example:"admin"`
+	ChangedAt   time.Time            `json:"changed_at" example:"2025-01-02T15:04:05Z"`
+	Before      *LoadSubResponce     `json:"before"`
+	After       *LoadSubResponce     `json:"after"`
+	PriceChange *PriceChangeResponce `json:"price_change,omitempty"`
 }
 
 type UpdateSubRequest struct {
-	Id          int       `json:"id" example:"1"`
-	ServiceName string    `json:"service_name" example:"Yandex Minus"`
-	Price       uint      `json:"price" example:"399"`
-	UserId      uuid.UUID `json:"user_id" example:"dceb1963-e152-47ff-a562-81a360627309"`
-	StartDate   string    `json:"start_date" example:"05-2025"`
-	EndDate     string    `json:"end_date,omitempty" example:"07-2025"`
+	ServiceName   string           `json:"service_name" example:"Yandex Minus" binding:"required,max=255"`
+	Price         *decimal.Decimal `json:"price" swaggertype:"string" example:"399.99" binding:"required,money"`
+	UserId        uuid.UUID        `json:"user_id" example:"dceb1963-e152-47ff-a562-81a360627309" binding:"required"`
+	StartDate     string           `json:"start_date" example:"17-05-2025" binding:"required,date"`
+	EndDate       string           `json:"end_date,omitempty" example:"07-2025" binding:"omitempty,date"`
+	BillingPeriod string           `json:"billing_period,omitempty" example:"monthly" binding:"omitempty,oneof=weekly monthly quarterly annual"`
+	Currency      string           `json:"currency,omitempty" example:"RUB" binding:"omitempty,currency"`
+}
+
+// PatchSubRequest is a JSON merge patch of a subscription, only the fields
+// present in the body are changed. A null end_date makes the subscription open-ended,
+// other fields must not be null.
+type PatchSubRequest struct {
+	ServiceName   *string          `json:"service_name,omitempty" example:"Yandex Minus" binding:"omitempty,min=1,max=255"`
+	Price         *decimal.Decimal `json:"price,omitempty" swaggertype:"string" example:"399.99" binding:"omitempty,money"`
+	UserId        *uuid.UUID       `json:"user_id,omitempty" example:"dceb1963-e152-47ff-a562-81a360627309"`
+	StartDate     *string          `json:"start_date,omitempty" example:"17-05-2025" binding:"omitempty,date"`
+	EndDate       NullableString   `json:"end_date" swaggertype:"string" example:"07-2025" binding:"omitempty,date"`
+	BillingPeriod *string          `json:"billing_period,omitempty" example:"annual" binding:"omitempty,oneof=weekly monthly quarterly annual"`
+	Currency      *string          `json:"currency,omitempty" example:"USD" binding:"omitempty,currency"`
+}
+
+// patchNotNull lists the patch fields that can't be set to null.
+var patchNotNull = []string{"service_name", "price", "user_id", "start_date", "billing_period", "currency"}
+
+// NullFieldsError is returned when a patch sets fields that can't be null to null.
+type NullFieldsError struct {
+	Fields []string
+}
+
+func (e *NullFieldsError) Error() string {
+	return fmt.Sprintf("fields must not be null: %s", strings.Join(e.Fields, ", "))
+}
+
+func (p *PatchSubRequest) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(data, &fields)
+	if err != nil {
+		return err
+	}
+
+	// без этой проверки null у поля неотличим от его отсутствия
+	var nulls []string
+	for _, name := range patchNotNull {
+		value, ok := fields[name]
+		if ok && string(bytes.TrimSpace(value)) == "null" {
+			nulls = append(nulls, name)
+		}
+	}
+	if len(nulls) != 0 {
+		return &NullFieldsError{Fields: nulls}
+	}
+
+	type plain PatchSubRequest
+	return json.Unmarshal(data, (*plain)(p))
+}
+
+// NullableString tells apart a field missing from the body, an explicit null and a value.
+type NullableString struct {
+	Set   bool
+	Null  bool
+	Value string
+}
+
+func (n *NullableString) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	if string(data) == "null" {
+		n.Null = true
+		return nil
+	}
+	return json.Unmarshal(data, &n.Value)
+}
+
+type PriceChangeRequest struct {
+	Price         *decimal.Decimal `json:"price" swaggertype:"string" example:"499.99" binding:"required,money"`
+	EffectiveFrom string           `json:"effective_from" example:"03-2025" binding:"required,date"`
 }
 
+type PriceChangeResponce struct {
+	Price         decimal.Decimal `json:"price" swaggertype:"string" example:"499.99"`
+	EffectiveFrom string          `json:"effective_from" example:"03-2025"`
+}
+
+// CostRequest filters the cost query, the cost is converted to
+// TargetCurrency (RUB by default) using the exchange rate of every charge month.
+// Dates are DD-MM-YYYY, YYYY-MM-DD or whole MM-YYYY, YYYY-MM months, both inclusive. Prorate charges
+// billing periods cut by the window or by the subscription dates only for their active days.
 type CostRequest struct {
-	ServiceName string    `json:"service_name" example:"Yandex Plus"`
-	UserId      uuid.UUID `json:"user_id" example:"dceb1963-e152-47ff-a562-81a360627309"`
-	StartDate   string    `json:"start_date" example:"01-2025"`
-	EndDate     string    `json:"end_date" example:"02-2025"`
+	ServiceName    string    `json:"service_name,omitempty" example:"Yandex Plus"`
+	UserId         uuid.UUID `json:"user_id,omitempty" example:"dceb1963-e152-47ff-a562-81a360627309"`
+	StartDate      string    `json:"start_date" example:"01-2025" binding:"required,date"`
+	EndDate        string    `json:"end_date" example:"02-2025" binding:"required,date"`
+	TargetCurrency string    `json:"target_currency,omitempty" example:"RUB" binding:"omitempty,currency"`
+	Prorate        bool      `json:"prorate,omitempty" example:"false"`
 }
 
+// CostRequestToDB holds the cost query filters, an empty ServiceName
+// or a uuid.Nil UserId means the filter is not applied.
 type CostRequestToDB struct {
 	ServiceName string
 	UserId      uuid.UUID
@@ -65,8 +210,74 @@ type CostRequestToDB struct {
 }
 
 type CostResponce struct {
-	ServiceName string    `json:"service_name" example:"Yandex Plus"`
-	UserId      uuid.UUID `json:"user_id" example:"dceb1963-e152-47ff-a562-81a360627309"`
-	Cost        int       `json:"cost" example:"900"`
-	MonthsCount int       `json:"months_count" example:"3"`
+	ServiceName   string          `json:"service_name,omitempty" example:"Yandex Plus"`
+	UserId        *uuid.UUID      `json:"user_id,omitempty" example:"dceb1963-e152-47ff-a562-81a360627309"`
+	Currency      string          `json:"currency" example:"RUB"`
+	Cost          decimal.Decimal `json:"cost" swaggertype:"string" example:"900"`
+	MonthsCount   int             `json:"months_count" example:"3"`
+	ChargesCount  int             `json:"charges_count" example:"3"`
+	Groups        []CostGroup     `json:"groups"`
+	Subscriptions []CostItem      `json:"subscriptions"`
+}
+
+// CostGroup is a subtotal for one service name and user pair, so requests
+// without a filter get the cost split by the omitted dimension.
+type CostGroup struct {
+	ServiceName  string          `json:"service_name" example:"Yandex Plus"`
+	UserId       uuid.UUID       `json:"user_id" example:"dceb1963-e152-47ff-a562-81a360627309"`
+	Cost         decimal.Decimal `json:"cost" swaggertype:"string" example:"900"`
+	MonthsCount  int             `json:"months_count" example:"3"`
+	ChargesCount int             `json:"charges_count" example:"3"`
+}
+
+type CostItem struct {
+	Id            int             `json:"id" example:"1"`
+	ServiceName   string          `json:"service_name" example:"Yandex Plus"`
+	UserId        uuid.UUID       `json:"user_id" example:"dceb1963-e152-47ff-a562-81a360627309"`
+	BillingPeriod string          `json:"billing_period" example:"monthly"`
+	Currency      string          `json:"currency" example:"USD"`
+	MonthsCount   int             `json:"months_count" example:"3"`
+	ChargesCount  int             `json:"charges_count" example:"3"`
+	Cost          decimal.Decimal `json:"cost" swaggertype:"string" example:"900"`
+}
+
+type CostMonthlyResponce struct {
+	ServiceName string          `json:"service_name,omitempty" example:"Yandex Plus"`
+	UserId      *uuid.UUID      `json:"user_id,omitempty" example:"dceb1963-e152-47ff-a562-81a360627309"`
+	Currency    string          `json:"currency" example:"RUB"`
+	Cost        decimal.Decimal `json:"cost" swaggertype:"string" example:"1200"`
+	Months      []CostMonth     `json:"months"`
+}
+
+type CostMonth struct {
+	Month         string          `json:"month" example:"01-2025"`
+	Cost          decimal.Decimal `json:"cost" swaggertype:"string" example:"400"`
+	Subscriptions []CostMonthItem `json:"subscriptions"`
+}
+
+type CostMonthItem struct {
+	Id            int             `json:"id" example:"1"`
+	ServiceName   string          `json:"service_name" example:"Yandex Plus"`
+	UserId        uuid.UUID       `json:"user_id" example:"dceb1963-e152-47ff-a562-81a360627309"`
+	BillingPeriod string          `json:"billing_period" example:"monthly"`
+	Currency      string          `json:"currency" example:"USD"`
+	ChargesCount  int             `json:"charges_count" example:"1"`
+	Cost          decimal.Decimal `json:"cost" swaggertype:"string" example:"400"`
+}
+
+type ExchangeRateRequest struct {
+	Currency string          `json:"currency" example:"USD"`
+	Month    string          `json:"month" example:"01-2025"`
+	Rate     decimal.Decimal `json:"rate" swaggertype:"string" example:"92.5"`
+}
+
+type ExchangeRateResponce struct {
+	Currency string          `json:"currency" example:"USD"`
+	Month    string          `json:"month" example:"01-2025"`
+	Rate     decimal.Decimal `json:"rate" swaggertype:"string" example:"92.5"`
+}
+
+type ImportRatesResponce struct {
+	Success bool `json:"success" example:"true"`
+	Count   int  `json:"count" example:"12"`
 }
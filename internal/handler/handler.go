@@ -1,91 +1,167 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
-	"main/internal/config"
-	"main/internal/interfaces"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 
 	ginSwagger "github.com/swaggo/gin-swagger"
+
+	"github.com/hollisgr/subservice/internal/actor"
+	"github.com/hollisgr/subservice/internal/config"
+	"github.com/hollisgr/subservice/internal/dates"
+	"github.com/hollisgr/subservice/internal/domain"
+	"github.com/hollisgr/subservice/internal/interfaces"
 )
 
 type handler struct {
-	router     *gin.Engine
-	subService interfaces.Subscriptions
+	router      *gin.Engine
+	subService  interfaces.Subscriptions
+	rateService interfaces.Rates
 }
 
-func New(r *gin.Engine, s interfaces.Subscriptions) interfaces.Handler {
+func New(r *gin.Engine, s interfaces.Subscriptions, rates interfaces.Rates) interfaces.Handler {
 	return &handler{
-		router:     r,
-		subService: s,
+		router:      r,
+		subService:  s,
+		rateService: rates,
 	}
 }
 
 func (h *handler) Register() {
 	initSwagger()
+	registerValidations()
 	cfg := config.GetConfig()
 	configCORS := cors.DefaultConfig()
 	configCORS.AllowOrigins = cfg.CORS.AllowOrigins
-	configCORS.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
+	configCORS.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "If-Match", "X-Actor"}
+	configCORS.ExposeHeaders = []string{"X-Total-Count", "ETag"}
 	configCORS.AllowCredentials = true
 
 	h.router.Use(cors.New(configCORS))
+	h.router.Use(actorMiddleware)
+	h.router.Use(errorMiddleware)
 
 	h.router.POST("/subscription", h.Create)
 	h.router.GET("/subscription/:id", h.Load)
 	h.router.GET("/subscription", h.LoadList)
-	h.router.PATCH("/subscription", h.Update)
+	h.router.PUT("/subscription/:id", h.Update)
+	h.router.PATCH("/subscription/:id", h.Patch)
 	h.router.DELETE("/subscription/:id", h.Delete)
+	h.router.GET("/subscription/:id/history", h.History)
+	h.router.POST("/subscription/:id/restore", h.Restore)
+	h.router.GET("/subscription/:id/price", h.Prices)
+	h.router.POST("/subscription/:id/price", h.SetPrice)
 	h.router.POST("/subscription/cost", h.Cost)
+	h.router.POST("/subscription/cost/monthly", h.CostMonthly)
+	h.router.GET("/exchange-rate", h.ListRates)
+	h.router.POST("/exchange-rate", h.SetRate)
+	h.router.POST("/exchange-rate/csv", h.ImportRates)
 
 	h.router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 }
 
-type ErrorBadRequest struct {
-	Success bool   `json:"success" example:"false"`
-	Status  string `json:"status" example:"bad request"`
-	Message string `json:"message" example:"error text"`
+// actorMiddleware puts the X-Actor header into the request context,
+// so storage can record who made a change.
+func actorMiddleware(c *gin.Context) {
+	name := strings.TrimSpace(c.GetHeader("X-Actor"))
+	if name != "" {
+		c.Request = c.Request.WithContext(actor.WithActor(c.Request.Context(), name))
+	}
+	c.Next()
 }
 
-type ErrorInternalError struct {
-	Success bool   `json:"success" example:"false"`
-	Status  string `json:"status" example:"internal error"`
-	Message string `json:"message" example:"error text"`
+// errorStatuses maps the domain error kinds to HTTP statuses,
+// errors of other kinds are internal errors.
+var errorStatuses = map[error]int{
+	domain.ErrNotFound:      http.StatusNotFound,
+	domain.ErrValidation:    http.StatusBadRequest,
+	domain.ErrConflict:      http.StatusConflict,
+	domain.ErrPrecondition:  http.StatusPreconditionFailed,
+	domain.ErrUnprocessable: http.StatusUnprocessableEntity,
 }
 
-type ErrorNotFound struct {
-	Success bool   `json:"success" example:"false"`
-	Status  string `json:"status" example:"entity not found"`
-	Message string `json:"message" example:"error text"`
+// errorMiddleware sends the last error a handler added with c.Error
+// as a problem, unless the handler already wrote a response.
+func errorMiddleware(c *gin.Context) {
+	c.Next()
+
+	if len(c.Errors) == 0 || c.Writer.Written() {
+		return
+	}
+	err := c.Errors.Last().Err
+
+	var dateErr *dates.ParseError
+	if errors.As(err, &dateErr) {
+		sendValidationError(c, FieldError{
+			Field:   dateErr.Field,
+			Code:    "date",
+			Message: dateErr.Err.Error(),
+		})
+		return
+	}
+
+	var domainErr *domain.Error
+	if !errors.As(err, &domainErr) {
+		// внутренние ошибки наружу не отдаем, они уже залогированы сервисом
+		sendProblem(c, http.StatusInternalServerError, "internal error", nil)
+		return
+	}
+
+	status, ok := errorStatuses[domainErr.Kind]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	if domainErr.Field != "" {
+		sendProblem(c, status, "request validation failed", []FieldError{{
+			Field:   domainErr.Field,
+			Code:    domainErr.Code,
+			Message: err.Error(),
+		}})
+		return
+	}
+	sendProblem(c, status, err.Error(), nil)
 }
 
 func sendBadRequest(c *gin.Context, msg string) {
-	c.AbortWithStatusJSON(http.StatusBadRequest, ErrorBadRequest{
-		Success: false,
-		Message: msg,
-		Status:  "bad request",
-	})
+	sendProblem(c, http.StatusBadRequest, msg, nil)
+}
+
+func sendPreconditionRequired(c *gin.Context, msg string) {
+	sendProblem(c, http.StatusPreconditionRequired, msg, nil)
 }
 
-func sendInternalError(c *gin.Context, msg string) {
-	c.AbortWithStatusJSON(http.StatusInternalServerError, ErrorInternalError{
-		Success: false,
-		Message: msg,
-		Status:  "internal error",
-	})
+// etag formats a subscription version as a strong entity tag.
+func etag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
 }
 
-func sendNotFound(c *gin.Context, msg string) {
-	c.AbortWithStatusJSON(http.StatusNotFound, ErrorNotFound{
-		Success: false,
-		Message: msg,
-		Status:  "entity not found",
-	})
+// getIfMatch returns the version from the If-Match header, "*" matches any
+// version and is returned as 0. ok is false when the header is missing.
+func getIfMatch(c *gin.Context) (version int, ok bool, err error) {
+	s := strings.TrimSpace(c.GetHeader("If-Match"))
+	if s == "" {
+		return 0, false, nil
+	}
+	if s == "*" {
+		return 0, true, nil
+	}
+	s, err = strconv.Unquote(strings.TrimPrefix(s, "W/"))
+	if err != nil {
+		return 0, true, err
+	}
+	version, err = strconv.Atoi(s)
+	if err != nil || version <= 0 {
+		return 0, true, fmt.Errorf("incorrect If-Match version: %q", s)
+	}
+	return version, true, nil
 }
 
 func getID(c *gin.Context) (id int, err error) {
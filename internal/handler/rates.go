@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/hollisgr/subservice/internal/dto"
+)
+
+// ListRates godoc
+//
+//	@Summary		Read exchange rates
+//	@Description	Returns the exchange rates to RUB ordered by currency and month, optionally filtered by currency.
+//	@Tags			Exchange rate
+//	@Produce		json
+//	@Param			currency	query		string	false	"Currency code"	example(USD)
+//	@Success		200			{array}		dto.ExchangeRateResponce
+//	@Failure		400			{object}	handler.Problem
+//	@Failure		500			{object}	handler.Problem
+//	@Router			/exchange-rate [get]
+func (h *handler) ListRates(c *gin.Context) {
+	resp, err := h.rateService.List(c.Request.Context(), strings.ToUpper(c.Query("currency")))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// SetRate godoc
+//
+//	@Summary		Set exchange rate
+//	@Description	Sets the amount of RUB for one unit of the currency from the given month on, an existing rate for the month is replaced.
+//	@Tags			Exchange rate
+//	@Accept			json
+//	@Produce		json
+//	@Param			rate	body		dto.ExchangeRateRequest	true	"Exchange rate data"
+//	@Success		200		{object}	dto.UpdateSubResponce
+//	@Failure		400		{object}	handler.Problem
+//	@Failure		500		{object}	handler.Problem
+//	@Router			/exchange-rate [post]
+func (h *handler) SetRate(c *gin.Context) {
+	req := dto.ExchangeRateRequest{}
+	err := bindJSON(c, &req)
+	if err != nil {
+		logrus.Warn("handler set rate err:", err)
+		return
+	}
+	req.Currency = strings.ToUpper(req.Currency)
+
+	err = h.rateService.Set(c.Request.Context(), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	resp := dto.UpdateSubResponce{
+		Success: true,
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ImportRates godoc
+//
+//	@Summary		Import exchange rates from CSV
+//	@Description	Stores the rates of a currency,month,rate CSV with an optional header row. The CSV is the request body or the "file" field of a multipart form. Nothing is stored if any row is incorrect.
+//	@Tags			Exchange rate
+//	@Accept			text/csv,multipart/form-data
+//	@Produce		json
+//	@Param			file	formData	file	false	"CSV file"
+//	@Success		200		{object}	dto.ImportRatesResponce
+//	@Failure		400		{object}	handler.Problem
+//	@Failure		500		{object}	handler.Problem
+//	@Router			/exchange-rate/csv [post]
+func (h *handler) ImportRates(c *gin.Context) {
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			sendBadRequest(c, "file required")
+			logrus.Warn("handler import rates err:", err)
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			sendBadRequest(c, "file err")
+			logrus.Warn("handler import rates err:", err)
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	count, err := h.rateService.Import(c.Request.Context(), body)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	resp := dto.ImportRatesResponce{
+		Success: true,
+		Count:   count,
+	}
+	c.JSON(http.StatusOK, resp)
+}
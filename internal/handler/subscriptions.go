@@ -1,15 +1,17 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
-	"main/internal/dto"
-	"main/internal/services/subscriptions"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
+
+	"github.com/hollisgr/subservice/internal/dto"
 )
 
 // Create godoc
@@ -21,29 +23,20 @@ import (
 //	@Produce		json
 //	@Param			subscription	body		dto.CreateSubRequest	true	"Subscription create data"
 //	@Success		200				{object}	dto.CreateSubResponce
-//	@Failure		400				{object}	handler.ErrorBadRequest
-//	@Failure		500				{object}	handler.ErrorInternalError
+//	@Failure		400				{object}	handler.Problem
+//	@Failure		500				{object}	handler.Problem
 //	@Router			/subscription [post]
 func (h *handler) Create(c *gin.Context) {
 	newSub := dto.CreateSubRequest{}
-	err := c.BindJSON(&newSub)
+	err := bindJSON(c, &newSub)
 	if err != nil {
-		sendBadRequest(c, "request body err")
 		logrus.Warn("handler create sub err:", err)
 		return
 	}
 
 	id, err := h.subService.Create(c.Request.Context(), newSub)
 	if err != nil {
-		if err == subscriptions.ErrIncorrectDate {
-			sendBadRequest(c, fmt.Sprintln(err))
-			return
-		}
-		if err == subscriptions.ErrEndIsLess {
-			sendBadRequest(c, "end date is less than start date")
-			return
-		}
-		sendInternalError(c, "create sub err")
+		c.Error(err)
 		return
 	}
 
@@ -62,9 +55,10 @@ func (h *handler) Create(c *gin.Context) {
 //	@Produce		json
 //	@Param			id	path		int	true	"Subscription ID"
 //	@Success		200	{object}	dto.LoadSubResponce
-//	@Failure		400	{object}	handler.ErrorBadRequest
-//	@Failure		404	{object}	handler.ErrorNotFound
-//	@Failure		500	{object}	handler.ErrorInternalError
+//	@Header			200	{string}	ETag	"subscription version, pass it in If-Match to update or delete"
+//	@Failure		400	{object}	handler.Problem
+//	@Failure		404	{object}	handler.Problem
+//	@Failure		500	{object}	handler.Problem
 //	@Router			/subscription/{id} [get]
 func (h *handler) Load(c *gin.Context) {
 	id, err := getID(c)
@@ -76,42 +70,59 @@ func (h *handler) Load(c *gin.Context) {
 
 	resp, err := h.subService.Load(c.Request.Context(), id)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			sendNotFound(c, "sub not found")
-			return
-		}
-		sendInternalError(c, "load sub err")
+		c.Error(err)
 		return
 	}
 
+	c.Header("ETag", etag(resp.Version))
 	c.JSON(http.StatusOK, resp)
 }
 
 // List godoc
 //
 //	@Summary		Read subscription list
-//	@Description	Returns a list of subscription objects
+//	@Description	Returns a filtered and sorted page of subscription objects, paginated by offset or by the next_cursor of the previous page, with the total count of matching subscriptions. An empty page is returned as an empty items array
 //	@Tags			Subscription
 //	@Produce		json
-//	@Param			offset	query		string	true	"offset"
-//	@Param			limit	query		string	true	"limit"
-//	@Success		200		{array}		dto.LoadSubResponce
-//	@Failure		400		{object}	handler.ErrorBadRequest
-//	@Failure		404		{object}	handler.ErrorNotFound
-//	@Failure		500		{object}	handler.ErrorInternalError
+//	@Param			offset			query		string	false	"offset, can't be combined with cursor"
+//	@Param			limit			query		string	true	"limit"
+//	@Param			cursor			query		string	false	"next_cursor of the previous page"
+//	@Param			user_id			query		string	false	"filter by user ID"
+//	@Param			service_name	query		string	false	"filter by exact service name"
+//	@Param			service_prefix	query		string	false	"filter by case-insensitive service name prefix"
+//	@Param			price_min		query		int		false	"minimal price"
+//	@Param			price_max		query		int		false	"maximal price"
+//	@Param			active_month	query		string	false	"subscriptions active in month, MM-YYYY"
+//	@Param			sort			query		string	false	"sort field, service_name is sorted case-insensitively"	Enums(id, price, start_date, end_date, service_name)
+//	@Param			order			query		string	false	"sort order"											Enums(asc, desc)
+//	@Success		200				{object}	dto.LoadListResponce
+//	@Header			200				{integer}	X-Total-Count	"total number of matching subscriptions"
+//	@Failure		400				{object}	handler.Problem
+//	@Failure		500				{object}	handler.Problem
 //	@Router			/subscription [get]
 func (h *handler) LoadList(c *gin.Context) {
-	offsetStr := c.Query("offset")
-	limitStr := c.Query("limit")
+	req := dto.LoadListRequest{
+		ServiceName:   c.Query("service_name"),
+		ServicePrefix: c.Query("service_prefix"),
+		ActiveMonth:   c.Query("active_month"),
+		Sort:          c.Query("sort"),
+		Order:         c.Query("order"),
+		Cursor:        c.Query("cursor"),
+	}
 
-	offset, err := convertToInt(offsetStr)
-	if err != nil {
-		logrus.Warn("handler loadlist err: params invalid offset value")
-		sendBadRequest(c, "params invalid offset value")
-		return
+	// offset не обязателен, без него список листается курсором
+	offset := 0
+	var err error
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		offset, err = convertToInt(offsetStr)
+		if err != nil {
+			logrus.Warn("handler loadlist err: params invalid offset value")
+			sendBadRequest(c, "params invalid offset value")
+			return
+		}
 	}
 
-	limit, err := convertToInt(limitStr)
+	limit, err := convertToInt(c.Query("limit"))
 	if err != nil {
 		logrus.Warn("handler loadlist err: params invalid limit value")
 		sendBadRequest(c, "params invalid limit value")
@@ -123,74 +134,151 @@ func (h *handler) LoadList(c *gin.Context) {
 		sendBadRequest(c, "limit or offset is less than 0")
 		return
 	}
+	req.Limit = limit
+	req.Offset = offset
 
-	resp, err := h.subService.LoadList(c.Request.Context(), limit, offset)
-	if err != nil {
-		if err == pgx.ErrNoRows {
-			sendNotFound(c, "sub list is empty")
+	if userId := c.Query("user_id"); userId != "" {
+		req.UserId, err = uuid.Parse(userId)
+		if err != nil {
+			logrus.Warn("handler loadlist err: params invalid user_id value")
+			sendBadRequest(c, "params invalid user_id value")
 			return
 		}
-		sendInternalError(c, "load sub list err")
+	}
+
+	req.PriceMin, err = convertToPrice(c.Query("price_min"))
+	if err != nil {
+		logrus.Warn("handler loadlist err: params invalid price_min value")
+		sendBadRequest(c, "params invalid price_min value")
 		return
 	}
+
+	req.PriceMax, err = convertToPrice(c.Query("price_max"))
+	if err != nil {
+		logrus.Warn("handler loadlist err: params invalid price_max value")
+		sendBadRequest(c, "params invalid price_max value")
+		return
+	}
+
+	resp, err := h.subService.LoadList(c.Request.Context(), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Header("X-Total-Count", strconv.Itoa(resp.Total))
 	c.JSON(http.StatusOK, resp)
 }
 
 // Update godoc
 //
-//	@Summary		Update subscription by ID
-//	@Description	Returns an ID updated subscription.
+//	@Summary		Replace subscription by ID
+//	@Description	Replaces every field of the subscription, a missing end_date makes it open-ended.
 //	@Tags			Subscription
 //	@Accept			json
 //	@Produce		json
+//	@Param			id				path		int						true	"Subscription ID"
+//	@Param			If-Match		header		string					true	"ETag of the subscription, * to skip the check"
 //	@Param			subscription	body		dto.UpdateSubRequest	true	"Subscription update data"
 //	@Success		200				{object}	dto.UpdateSubResponce
-//	@Failure		400				{object}	handler.ErrorBadRequest
-//	@Failure		404				{object}	handler.ErrorNotFound
-//	@Failure		500				{object}	handler.ErrorInternalError
-//	@Router			/subscription [patch]
+//	@Header			200				{string}	ETag	"new subscription version"
+//	@Failure		400				{object}	handler.Problem
+//	@Failure		404				{object}	handler.Problem
+//	@Failure		409				{object}	handler.Problem
+//	@Failure		412				{object}	handler.Problem
+//	@Failure		428				{object}	handler.Problem
+//	@Failure		500				{object}	handler.Problem
+//	@Router			/subscription/{id} [put]
 func (h *handler) Update(c *gin.Context) {
+	id, err := getID(c)
+	if err != nil {
+		sendBadRequest(c, "sub id required")
+		logrus.Warn("handler update sub err:", err)
+		return
+	}
+	version, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
 	req := dto.UpdateSubRequest{}
-	err := c.BindJSON(&req)
+	err = bindJSON(c, &req)
 	if err != nil {
-		sendBadRequest(c, "request body err")
 		logrus.Warn("handler update sub err:", err)
 		return
 	}
-	err = h.subService.Update(c.Request.Context(), req)
+	version, err = h.subService.Update(c.Request.Context(), id, version, req)
 	if err != nil {
-		if err == subscriptions.ErrIncorrectDate {
-			sendBadRequest(c, fmt.Sprintln(err))
-			return
-		}
-		if err == subscriptions.ErrEndIsLess {
-			sendBadRequest(c, "end date is less than start date")
-			return
-		}
-		if err == pgx.ErrNoRows {
-			sendNotFound(c, "sub not found")
-			return
-		}
-		sendInternalError(c, "update sub err")
+		c.Error(err)
 		return
 	}
 	resp := dto.UpdateSubResponce{
 		Success: true,
 	}
+	c.Header("ETag", etag(version))
+	c.JSON(http.StatusOK, resp)
+}
+
+// Patch godoc
+//
+//	@Summary		Partially update subscription by ID
+//	@Description	Applies a JSON merge patch, only the fields present in the body are changed. A null end_date makes the subscription open-ended.
+//	@Tags			Subscription
+//	@Accept			json
+//	@Accept			application/merge-patch+json
+//	@Produce		json
+//	@Param			id				path		int					true	"Subscription ID"
+//	@Param			subscription	body		dto.PatchSubRequest	true	"Subscription patch data"
+//	@Success		200				{object}	dto.UpdateSubResponce
+//	@Header			200				{string}	ETag	"new subscription version"
+//	@Failure		400				{object}	handler.Problem
+//	@Failure		404				{object}	handler.Problem
+//	@Failure		409				{object}	handler.Problem
+//	@Failure		412				{object}	handler.Problem
+//	@Failure		428				{object}	handler.Problem
+//	@Failure		500				{object}	handler.Problem
+//	@Router			/subscription/{id} [patch]
+func (h *handler) Patch(c *gin.Context) {
+	id, err := getID(c)
+	if err != nil {
+		sendBadRequest(c, "sub id required")
+		logrus.Warn("handler patch sub err:", err)
+		return
+	}
+	version, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+	req := dto.PatchSubRequest{}
+	err = bindJSON(c, &req)
+	if err != nil {
+		logrus.Warn("handler patch sub err:", err)
+		return
+	}
+	version, err = h.subService.Patch(c.Request.Context(), id, version, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	resp := dto.UpdateSubResponce{
+		Success: true,
+	}
+	c.Header("ETag", etag(version))
 	c.JSON(http.StatusOK, resp)
 }
 
 // Delete godoc
 //
 //	@Summary		Delete subscription by ID
-//	@Description	Returns an ID deleted subscription.
+//	@Description	Soft-deletes the subscription, it can be restored until the retention period purges it.
 //	@Tags			Subscription
 //	@Produce		json
-//	@Param			id	path		int	true	"Subscription ID"
-//	@Success		200	{object}	dto.DeleteSubResponce
-//	@Failure		400	{object}	handler.ErrorBadRequest
-//	@Failure		404	{object}	handler.ErrorNotFound
-//	@Failure		500	{object}	handler.ErrorInternalError
+//	@Param			id			path		int		true	"Subscription ID"
+//	@Param			If-Match	header		string	true	"ETag of the subscription, * to skip the check"
+//	@Success		200			{object}	dto.DeleteSubResponce
+//	@Failure		400			{object}	handler.Problem
+//	@Failure		404			{object}	handler.Problem
+//	@Failure		412			{object}	handler.Problem
+//	@Failure		428			{object}	handler.Problem
+//	@Failure		500			{object}	handler.Problem
 //	@Router			/subscription/{id} [delete]
 func (h *handler) Delete(c *gin.Context) {
 	id, err := getID(c)
@@ -199,13 +287,13 @@ func (h *handler) Delete(c *gin.Context) {
 		logrus.Warn("handler delete sub err:", err)
 		return
 	}
-	err = h.subService.Delete(c.Request.Context(), id)
+	version, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+	err = h.subService.Delete(c.Request.Context(), id, version)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			sendNotFound(c, "sub not found")
-			return
-		}
-		sendInternalError(c, "delete sub err")
+		c.Error(err)
 		return
 	}
 	resp := dto.DeleteSubResponce{
@@ -214,43 +302,198 @@ func (h *handler) Delete(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+// Restore godoc
+//
+//	@Summary		Restore deleted subscription by ID
+//	@Description	Brings back a soft-deleted subscription that has not been purged yet.
+//	@Tags			Subscription
+//	@Produce		json
+//	@Param			id	path		int	true	"Subscription ID"
+//	@Success		200	{object}	dto.UpdateSubResponce
+//	@Header			200	{string}	ETag	"new subscription version"
+//	@Failure		400	{object}	handler.Problem
+//	@Failure		404	{object}	handler.Problem
+//	@Failure		409	{object}	handler.Problem
+//	@Failure		500	{object}	handler.Problem
+//	@Router			/subscription/{id}/restore [post]
+func (h *handler) Restore(c *gin.Context) {
+	id, err := getID(c)
+	if err != nil {
+		sendBadRequest(c, "sub id required")
+		logrus.Warn("handler restore sub err:", err)
+		return
+	}
+	version, err := h.subService.Restore(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	resp := dto.UpdateSubResponce{
+		Success: true,
+	}
+	c.Header("ETag", etag(version))
+	c.JSON(http.StatusOK, resp)
+}
+
+// History godoc
+//
+//	@Summary		Read subscription change history
+//	@Description	Returns the audit trail of the subscription with snapshots before and after every change.
+//	@Tags			Subscription
+//	@Produce		json
+//	@Param			id	path		int	true	"Subscription ID"
+//	@Success		200	{array}		dto.HistoryResponce
+//	@Failure		400	{object}	handler.Problem
+//	@Failure		404	{object}	handler.Problem
+//	@Failure		500	{object}	handler.Problem
+//	@Router			/subscription/{id}/history [get]
+func (h *handler) History(c *gin.Context) {
+	id, err := getID(c)
+	if err != nil {
+		sendBadRequest(c, "sub id required")
+		logrus.Warn("handler history sub err:", err)
+		return
+	}
+
+	resp, err := h.subService.History(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// SetPrice godoc
+//
+//	@Summary		Schedule subscription price change
+//	@Description	Sets the subscription price from the given month on, cost of earlier months keeps the previous price. A change for the same month is replaced. The change is recorded in the history and bumps the subscription version.
+//	@Tags			Subscription
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int						true	"Subscription ID"
+//	@Param			If-Match	header		string					true	"ETag of the subscription, * to skip the check"
+//	@Param			price		body		dto.PriceChangeRequest	true	"Price change data"
+//	@Success		200			{object}	dto.UpdateSubResponce
+//	@Header			200			{string}	ETag	"new subscription version"
+//	@Failure		400			{object}	handler.Problem
+//	@Failure		404			{object}	handler.Problem
+//	@Failure		412			{object}	handler.Problem
+//	@Failure		428			{object}	handler.Problem
+//	@Failure		500			{object}	handler.Problem
+//	@Router			/subscription/{id}/price [post]
+func (h *handler) SetPrice(c *gin.Context) {
+	id, err := getID(c)
+	if err != nil {
+		sendBadRequest(c, "sub id required")
+		logrus.Warn("handler set price sub err:", err)
+		return
+	}
+	version, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+	req := dto.PriceChangeRequest{}
+	err = bindJSON(c, &req)
+	if err != nil {
+		logrus.Warn("handler set price sub err:", err)
+		return
+	}
+	version, err = h.subService.SetPrice(c.Request.Context(), id, version, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	resp := dto.UpdateSubResponce{
+		Success: true,
+	}
+	c.Header("ETag", etag(version))
+	c.JSON(http.StatusOK, resp)
+}
+
+// Prices godoc
+//
+//	@Summary		Read subscription price changes
+//	@Description	Returns the scheduled price changes of the subscription ordered by effective date.
+//	@Tags			Subscription
+//	@Produce		json
+//	@Param			id	path		int	true	"Subscription ID"
+//	@Success		200	{array}		dto.PriceChangeResponce
+//	@Failure		400	{object}	handler.Problem
+//	@Failure		404	{object}	handler.Problem
+//	@Failure		500	{object}	handler.Problem
+//	@Router			/subscription/{id}/price [get]
+func (h *handler) Prices(c *gin.Context) {
+	id, err := getID(c)
+	if err != nil {
+		sendBadRequest(c, "sub id required")
+		logrus.Warn("handler prices sub err:", err)
+		return
+	}
+
+	resp, err := h.subService.Prices(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
 // Cost godoc
 //
 //	@Summary		Cost subscription
-//	@Description	Returns a cost of subscriptions by user ID, date and service name
+//	@Description	Returns a total cost of billing charges falling into the period, optionally filtered by user ID and service name, grouped by service and user with a per-subscription breakdown, converted to the target currency
 //	@Tags			Subscription
 //	@Accept			json
 //	@Produce		json
-//	@Param			subscription	body		dto.CostRequest	true	"Subscription update data"
+//	@Param			subscription	body		dto.CostRequest	true	"Subscription cost filters"
 //	@Success		200				{object}	dto.CostResponce
-//	@Failure		400				{object}	handler.ErrorBadRequest
-//	@Failure		404				{object}	handler.ErrorNotFound
-//	@Failure		500				{object}	handler.ErrorInternalError
+//	@Failure		400				{object}	handler.Problem
+//	@Failure		422				{object}	handler.Problem
+//	@Failure		500				{object}	handler.Problem
 //	@Router			/subscription/cost [post]
 func (h *handler) Cost(c *gin.Context) {
 	req := dto.CostRequest{}
-	err := c.BindJSON(&req)
+	err := bindJSON(c, &req)
 	if err != nil {
-		sendBadRequest(c, "request body err")
 		logrus.Warn("handler cost sub err:", err)
 		return
 	}
 
 	resp, err := h.subService.Cost(c.Request.Context(), req)
 	if err != nil {
-		if err == subscriptions.ErrIncorrectDate {
-			sendBadRequest(c, fmt.Sprintln(err))
-			return
-		}
-		if err == subscriptions.ErrEndIsLess {
-			sendBadRequest(c, "end date is less than start date")
-			return
-		}
-		if err == pgx.ErrNoRows {
-			sendNotFound(c, "sub not found")
-			return
-		}
-		sendInternalError(c, "cost sub err")
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// CostMonthly godoc
+//
+//	@Summary		Monthly cost of subscriptions
+//	@Description	Returns a month-by-month cost of billing charges for the period, optionally filtered by user ID and service name, with the contributing subscriptions of each month, converted to the target currency
+//	@Tags			Subscription
+//	@Accept			json
+//	@Produce		json
+//	@Param			subscription	body		dto.CostRequest	true	"Subscription cost filters"
+//	@Success		200				{object}	dto.CostMonthlyResponce
+//	@Failure		400				{object}	handler.Problem
+//	@Failure		422				{object}	handler.Problem
+//	@Failure		500				{object}	handler.Problem
+//	@Router			/subscription/cost/monthly [post]
+func (h *handler) CostMonthly(c *gin.Context) {
+	req := dto.CostRequest{}
+	err := bindJSON(c, &req)
+	if err != nil {
+		logrus.Warn("handler cost monthly sub err:", err)
+		return
+	}
+
+	resp, err := h.subService.CostMonthly(c.Request.Context(), req)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -259,7 +502,7 @@ func (h *handler) Cost(c *gin.Context) {
 
 func convertToInt(str string) (int, error) {
 	if str == "" {
-		return 0, subscriptions.ErrIncorrectValue
+		return 0, errors.New("incorrect number")
 	}
 
 	num, err := strconv.ParseInt(str, 10, 64)
@@ -269,3 +512,36 @@ func convertToInt(str string) (int, error) {
 
 	return int(num), nil
 }
+
+// convertToPrice parses an optional price query value, empty means not set.
+func convertToPrice(str string) (*decimal.Decimal, error) {
+	if str == "" {
+		return nil, nil
+	}
+
+	price, err := decimal.NewFromString(str)
+	if err != nil {
+		return nil, err
+	}
+	if price.IsNegative() {
+		return nil, fmt.Errorf("negative price: %s", str)
+	}
+
+	return &price, nil
+}
+
+// requireIfMatch reads the If-Match version and sends an error response
+// when the header is missing or malformed.
+func requireIfMatch(c *gin.Context) (int, bool) {
+	version, ok, err := getIfMatch(c)
+	if !ok {
+		sendPreconditionRequired(c, "If-Match header required")
+		return 0, false
+	}
+	if err != nil {
+		sendBadRequest(c, "invalid If-Match header")
+		logrus.Warn("handler if-match err:", err)
+		return 0, false
+	}
+	return version, true
+}
@@ -1,8 +1,8 @@
 package handler
 
 import (
-	"main/docs"
-	"main/internal/config"
+	"github.com/hollisgr/subservice/docs"
+	"github.com/hollisgr/subservice/internal/config"
 )
 
 func initSwagger() {
@@ -0,0 +1,242 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/hollisgr/subservice/internal/dates"
+	"github.com/hollisgr/subservice/internal/dto"
+	"github.com/hollisgr/subservice/internal/model"
+)
+
+// Problem is an RFC 7807 problem details response,
+// Errors lists the invalid request fields.
+type Problem struct {
+	Type   string       `json:"type" example:"about:blank"`
+	Title  string       `json:"title" example:"Bad Request"`
+	Status int          `json:"status" example:"400"`
+	Detail string       `json:"detail,omitempty" example:"request validation failed"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError is an invalid request field, Code is the failed rule.
+type FieldError struct {
+	Field   string `json:"field" example:"start_date"`
+	Code    string `json:"code" example:"date"`
+	Message string `json:"message" example:"expected DD-MM-YYYY, YYYY-MM-DD, MM-YYYY or YYYY-MM"`
+}
+
+// registerValidations adds the rules used in dto binding tags and makes
+// validation errors name the fields as they are named in JSON.
+func registerValidations() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	// decimal и uuid проверяем в строковом виде, пустой uuid не считается заданным
+	v.RegisterCustomTypeFunc(func(field reflect.Value) any {
+		return field.Interface().(decimal.Decimal).String()
+	}, decimal.Decimal{})
+	// у end_date в патче проверяется только значение, null разрешён
+	v.RegisterCustomTypeFunc(func(field reflect.Value) any {
+		date := field.Interface().(dto.NullableString)
+		if !date.Set || date.Null {
+			return ""
+		}
+		return date.Value
+	}, dto.NullableString{})
+	v.RegisterCustomTypeFunc(func(field reflect.Value) any {
+		id := field.Interface().(uuid.UUID)
+		if id == uuid.Nil {
+			return ""
+		}
+		return id.String()
+	}, uuid.UUID{})
+
+	v.RegisterValidation("date", func(fl validator.FieldLevel) bool {
+		_, _, err := dates.Parse(fl.FieldName(), fl.Field().String())
+		return err == nil
+	})
+	v.RegisterValidation("money", func(fl validator.FieldLevel) bool {
+		price, err := decimal.NewFromString(fl.Field().String())
+		return err == nil && model.IsPrice(price)
+	})
+	v.RegisterValidation("currency", func(fl validator.FieldLevel) bool {
+		return model.IsCurrency(fl.Field().String())
+	})
+}
+
+// bindJSON decodes and validates the request body, on failure it sends
+// a problem listing the invalid fields and returns the error.
+func bindJSON(c *gin.Context, obj any) error {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		sendBadRequest(c, fmt.Sprintf("request body err: %v", err))
+		return err
+	}
+
+	err = binding.JSON.BindBody(body, obj)
+	if err == nil {
+		return nil
+	}
+
+	res := fieldErrors(err)
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		// разбор тела останавливается на первом неверном uuid или decimal,
+		// поэтому поля разбираем по одному и проверяем тело без неверных полей
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(body, &fields) != nil {
+			sendBadRequest(c, fmt.Sprintf("request body err: %v", err))
+			return err
+		}
+
+		objType := reflect.TypeOf(obj).Elem()
+		res = decodeErrors(fields, objType)
+		for _, fe := range res {
+			delete(fields, fe.Field)
+		}
+
+		rest, _ := json.Marshal(fields)
+		restErr := binding.JSON.BindBody(rest, reflect.New(objType).Interface())
+		for _, fe := range fieldErrors(restErr) {
+			if !slices.ContainsFunc(res, func(r FieldError) bool { return r.Field == fe.Field }) {
+				res = append(res, fe)
+			}
+		}
+	}
+
+	if len(res) == 0 {
+		sendBadRequest(c, fmt.Sprintf("request body err: %v", err))
+		return err
+	}
+
+	sendValidationError(c, res...)
+	return err
+}
+
+// fieldErrors returns the invalid fields of a validation or null fields error.
+func fieldErrors(err error) []FieldError {
+	var validationErrs validator.ValidationErrors
+	var nullErr *dto.NullFieldsError
+	switch {
+	case errors.As(err, &validationErrs):
+		res := make([]FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			res = append(res, FieldError{
+				Field:   fe.Field(),
+				Code:    fe.Tag(),
+				Message: validationMessage(fe),
+			})
+		}
+		return res
+	case errors.As(err, &nullErr):
+		res := make([]FieldError, 0, len(nullErr.Fields))
+		for _, field := range nullErr.Fields {
+			res = append(res, FieldError{
+				Field:   field,
+				Code:    "not_null",
+				Message: "must not be null",
+			})
+		}
+		return res
+	}
+	return nil
+}
+
+// decodeErrors decodes every body field into its struct field type
+// and returns the fields that can't be decoded.
+func decodeErrors(fields map[string]json.RawMessage, objType reflect.Type) []FieldError {
+	var res []FieldError
+	for i := range objType.NumField() {
+		field := objType.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		raw, ok := fields[name]
+		if !ok || name == "" || name == "-" {
+			continue
+		}
+
+		err := json.Unmarshal(raw, reflect.New(field.Type).Interface())
+		if err == nil {
+			continue
+		}
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case fieldType == reflect.TypeOf(uuid.UUID{}):
+			res = append(res, FieldError{Field: name, Code: "uuid", Message: "must be a UUID"})
+		case fieldType == reflect.TypeOf(decimal.Decimal{}):
+			res = append(res, FieldError{Field: name, Code: "decimal", Message: "must be a decimal number"})
+		case errors.As(err, &typeErr):
+			res = append(res, FieldError{Field: name, Code: "type", Message: fmt.Sprintf("must be %s", typeErr.Type)})
+		default:
+			res = append(res, FieldError{Field: name, Code: "type", Message: "is invalid"})
+		}
+	}
+	return res
+}
+
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		if fe.Param() == "1" {
+			return "must not be empty"
+		}
+		return fmt.Sprintf("must be at least %s characters long", fe.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s characters long", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of %s", strings.ReplaceAll(fe.Param(), " ", ", "))
+	case "date":
+		return dates.ErrFormat.Error()
+	case "money":
+		return "must be a non-negative amount with at most two decimal places"
+	case "currency":
+		return "must be a three letter ISO 4217 code"
+	}
+	return "is invalid"
+}
+
+func sendProblem(c *gin.Context, status int, detail string, errs []FieldError) {
+	c.Header("Content-Type", "application/problem+json")
+	c.AbortWithStatusJSON(status, Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Errors: errs,
+	})
+}
+
+func sendValidationError(c *gin.Context, errs ...FieldError) {
+	sendProblem(c, http.StatusBadRequest, "request validation failed", errs)
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/hollisgr/subservice/internal/dto"
+)
+
+func TestBindJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	registerValidations()
+
+	tests := []struct {
+		name   string
+		obj    any
+		body   string
+		status int
+		want   []FieldError
+	}{
+		{
+			name:   "empty user id",
+			obj:    &dto.CreateSubRequest{},
+			body:   `{"service_name": "Okko", "price": "100", "user_id": "", "start_date": "01-2025"}`,
+			status: http.StatusBadRequest,
+			want:   []FieldError{{Field: "user_id", Code: "uuid"}},
+		},
+		{
+			name:   "invalid price",
+			obj:    &dto.CreateSubRequest{},
+			body:   `{"service_name": "Okko", "price": "abc", "user_id": "dceb1963-e152-47ff-a562-81a360627309", "start_date": "01-2025"}`,
+			status: http.StatusBadRequest,
+			want:   []FieldError{{Field: "price", Code: "decimal"}},
+		},
+		{
+			name:   "invalid user id, price and other fields",
+			obj:    &dto.CreateSubRequest{},
+			body:   `{"price": "abc", "user_id": "1", "start_date": "2025", "currency": "rub"}`,
+			status: http.StatusBadRequest,
+			want: []FieldError{
+				{Field: "price", Code: "decimal"},
+				{Field: "user_id", Code: "uuid"},
+				{Field: "service_name", Code: "required"},
+				{Field: "start_date", Code: "date"},
+				{Field: "currency", Code: "currency"},
+			},
+		},
+		{
+			name:   "patch with invalid user id and null price",
+			obj:    &dto.PatchSubRequest{},
+			body:   `{"user_id": "", "price": null}`,
+			status: http.StatusBadRequest,
+			want: []FieldError{
+				{Field: "user_id", Code: "uuid"},
+				{Field: "price", Code: "not_null"},
+			},
+		},
+		{
+			name:   "wrong type",
+			obj:    &dto.CreateSubRequest{},
+			body:   `{"service_name": 5, "price": "100", "user_id": "dceb1963-e152-47ff-a562-81a360627309", "start_date": "01-2025"}`,
+			status: http.StatusBadRequest,
+			want:   []FieldError{{Field: "service_name", Code: "type"}},
+		},
+		{
+			name:   "malformed body",
+			obj:    &dto.CreateSubRequest{},
+			body:   `{"service_name": `,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "valid",
+			obj:    &dto.CreateSubRequest{},
+			body:   `{"service_name": "Okko", "price": "100", "user_id": "dceb1963-e152-47ff-a562-81a360627309", "start_date": "01-2025"}`,
+			status: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+
+			err := bindJSON(c, tt.obj)
+			if tt.status == http.StatusOK {
+				if err != nil {
+					t.Fatalf("bindJSON: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("bindJSON returned no error")
+			}
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d", w.Code, tt.status)
+			}
+
+			var problem Problem
+			err = json.Unmarshal(w.Body.Bytes(), &problem)
+			if err != nil {
+				t.Fatalf("decode problem: %v", err)
+			}
+			if len(problem.Errors) != len(tt.want) {
+				t.Fatalf("errors %+v, want %+v", problem.Errors, tt.want)
+			}
+			for _, want := range tt.want {
+				found := slices.ContainsFunc(problem.Errors, func(got FieldError) bool {
+					return got.Field == want.Field && got.Code == want.Code
+				})
+				if !found {
+					t.Errorf("errors %+v, want %s: %s", problem.Errors, want.Field, want.Code)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+
+	"github.com/hollisgr/subservice/internal/dto"
+)
+
+type Rates interface {
+	Set(ctx context.Context, data dto.ExchangeRateRequest) error
+	Import(ctx context.Context, r io.Reader) (int, error)
+	List(ctx context.Context, currency string) ([]dto.ExchangeRateResponce, error)
+}
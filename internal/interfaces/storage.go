@@ -2,15 +2,25 @@ package interfaces
 
 import (
 	"context"
-	"main/internal/dto"
-	"main/internal/model"
+	"time"
+
+	"github.com/hollisgr/subservice/internal/dto"
+	"github.com/hollisgr/subservice/internal/model"
 )
 
 type Storage interface {
-	Delete(ctx context.Context, id int) error
-	Update(ctx context.Context, sub model.Subscription) error
-	LoadList(ctx context.Context, limit int, offset int) ([]model.Subscription, error)
+	Delete(ctx context.Context, id int, version int) error
+	Update(ctx context.Context, sub model.Subscription) (int, error)
+	LoadList(ctx context.Context, filter dto.LoadListRequestToDB) ([]model.Subscription, error)
+	Count(ctx context.Context, filter dto.LoadListRequestToDB) (int, error)
 	Load(ctx context.Context, id int) (model.Subscription, error)
 	Create(ctx context.Context, sub model.Subscription) (int, error)
-	Cost(ctx context.Context, data dto.CostRequestToDB) (model.Subscription, error)
+	Cost(ctx context.Context, data dto.CostRequestToDB) ([]model.Subscription, error)
+	History(ctx context.Context, id int) ([]model.HistoryEntry, error)
+	Restore(ctx context.Context, id int) (int, error)
+	Purge(ctx context.Context, before time.Time) (int, error)
+	SetPrice(ctx context.Context, change model.PriceChange, version int) (int, error)
+	Prices(ctx context.Context, ids []int) ([]model.PriceChange, error)
+	SetRates(ctx context.Context, rates []model.ExchangeRate) error
+	Rates(ctx context.Context, currencies []string, to time.Time) ([]model.ExchangeRate, error)
 }
@@ -2,14 +2,23 @@ package interfaces
 
 import (
 	"context"
-	"main/internal/dto"
+	"time"
+
+	"github.com/hollisgr/subservice/internal/dto"
 )
 
 type Subscriptions interface {
 	Create(ctx context.Context, data dto.CreateSubRequest) (int, error)
 	Load(ctx context.Context, id int) (dto.LoadSubResponce, error)
-	LoadList(ctx context.Context, limit int, offset int) ([]dto.LoadSubResponce, error)
-	Update(ctx context.Context, data dto.UpdateSubRequest) error
-	Delete(ctx context.Context, id int) error
+	LoadList(ctx context.Context, data dto.LoadListRequest) (dto.LoadListResponce, error)
+	Update(ctx context.Context, id int, version int, data dto.UpdateSubRequest) (int, error)
+	Patch(ctx context.Context, id int, version int, data dto.PatchSubRequest) (int, error)
+	Delete(ctx context.Context, id int, version int) error
+	History(ctx context.Context, id int) ([]dto.HistoryResponce, error)
+	Restore(ctx context.Context, id int) (int, error)
+	Purge(ctx context.Context, retention time.Duration) (int, error)
+	SetPrice(ctx context.Context, id int, version int, data dto.PriceChangeRequest) (int, error)
+	Prices(ctx context.Context, id int) ([]dto.PriceChangeResponce, error)
 	Cost(ctx context.Context, data dto.CostRequest) (dto.CostResponce, error)
+	CostMonthly(ctx context.Context, data dto.CostRequest) (dto.CostMonthlyResponce, error)
 }
@@ -2,67 +2,207 @@ package mappers
 
 import (
 	"fmt"
-	"main/internal/dto"
-	"main/internal/model"
-	"strings"
 	"time"
+
+	"github.com/hollisgr/subservice/internal/dates"
+	"github.com/hollisgr/subservice/internal/dto"
+	"github.com/hollisgr/subservice/internal/model"
 )
 
-func CreateWebToModel(data dto.CreateSubRequest) model.Subscription {
+func CreateWebToModel(data dto.CreateSubRequest) (model.Subscription, error) {
 	res := model.Subscription{
-		ServiceName: data.ServiceName,
-		Price:       data.Price,
-		UserId:      data.UserId,
-		StartDate:   ConvertStringToDate(data.StartDate),
+		ServiceName:   data.ServiceName,
+		UserId:        data.UserId,
+		BillingPeriod: data.BillingPeriod,
+		Currency:      data.Currency,
 	}
-	if data.EndDate != "" {
-		res.EndDate = ConvertStringToDate(data.EndDate)
+	if data.Price != nil {
+		res.Price = *data.Price
 	}
-	return res
+	var err error
+	res.StartDate, res.EndDate, err = convertPeriod(data.StartDate, data.EndDate)
+	return res, err
 }
 
 func ModelToLoadWeb(data model.Subscription) dto.LoadSubResponce {
 	res := dto.LoadSubResponce{
-		Id:          data.Id,
-		ServiceName: data.ServiceName,
-		Price:       data.Price,
-		UserId:      data.UserId,
-		StartDate:   ConvertDateToString(data.StartDate),
+		Id:            data.Id,
+		ServiceName:   data.ServiceName,
+		Price:         data.Price,
+		UserId:        data.UserId,
+		StartDate:     ConvertStartToString(data.StartDate),
+		BillingPeriod: data.BillingPeriod,
+		Currency:      data.Currency,
+		Version:       data.Version,
 	}
-	if data.EndDate != time.Unix(0, 0) {
-		res.EndDate = ConvertDateToString(data.EndDate)
+	if data.EndDate != nil {
+		res.EndDate = ConvertEndToString(*data.EndDate)
 	}
 	return res
 }
 
-func UpdateWebToModel(data dto.UpdateSubRequest) model.Subscription {
-	res := model.Subscription{
-		Id:          data.Id,
-		ServiceName: data.ServiceName,
-		Price:       data.Price,
-		UserId:      data.UserId,
-		StartDate:   ConvertStringToDate(data.StartDate),
+func HistoryToWeb(data model.HistoryEntry) dto.HistoryResponce {
+	res := dto.HistoryResponce{
+		Id:        data.Id,
+		Action:    data.Action,
+		Actor:     data.Actor,
+		ChangedAt: data.ChangedAt,
+	}
+	if data.Before != nil {
+		before := ModelToLoadWeb(*data.Before)
+		res.Before = &before
+	}
+	if data.After != nil {
+		after := ModelToLoadWeb(*data.After)
+		res.After = &after
 	}
-	if data.EndDate != "" {
-		res.EndDate = ConvertStringToDate(data.EndDate)
+	if data.PriceChange != nil {
+		change := PriceChangeToWeb(*data.PriceChange)
+		res.PriceChange = &change
 	}
 	return res
 }
 
-func CostRequestToCostDB(data dto.CostRequest) dto.CostRequestToDB {
-	return dto.CostRequestToDB{
+func UpdateWebToModel(id int, data dto.UpdateSubRequest) (model.Subscription, error) {
+	res := model.Subscription{
+		Id:            id,
+		ServiceName:   data.ServiceName,
+		UserId:        data.UserId,
+		BillingPeriod: data.BillingPeriod,
+		Currency:      data.Currency,
+	}
+	if data.Price != nil {
+		res.Price = *data.Price
+	}
+	var err error
+	res.StartDate, res.EndDate, err = convertPeriod(data.StartDate, data.EndDate)
+	return res, err
+}
+
+// convertPeriod parses the subscription start date and the optional end date,
+// an empty end date means the subscription is open-ended.
+func convertPeriod(startStr, endStr string) (start time.Time, end *time.Time, err error) {
+	start, err = dates.ParseStart("start_date", startStr)
+	if err != nil {
+		return start, nil, err
+	}
+	if endStr == "" {
+		return start, nil, nil
+	}
+	date, err := dates.ParseEnd("end_date", endStr)
+	if err != nil {
+		return start, nil, err
+	}
+	return start, &date, nil
+}
+
+// PatchToModel applies the fields present in the patch to the subscription.
+func PatchToModel(sub model.Subscription, data dto.PatchSubRequest) (model.Subscription, error) {
+	if data.ServiceName != nil {
+		sub.ServiceName = *data.ServiceName
+	}
+	if data.Price != nil {
+		sub.Price = *data.Price
+	}
+	if data.UserId != nil {
+		sub.UserId = *data.UserId
+	}
+	if data.StartDate != nil {
+		start, err := dates.ParseStart("start_date", *data.StartDate)
+		if err != nil {
+			return sub, err
+		}
+		sub.StartDate = start
+	}
+	if data.BillingPeriod != nil {
+		sub.BillingPeriod = *data.BillingPeriod
+	}
+	if data.Currency != nil {
+		sub.Currency = *data.Currency
+	}
+	if data.EndDate.Set {
+		sub.EndDate = nil
+		if !data.EndDate.Null {
+			end, err := dates.ParseEnd("end_date", data.EndDate.Value)
+			if err != nil {
+				return sub, err
+			}
+			sub.EndDate = &end
+		}
+	}
+	return sub, nil
+}
+
+func PriceChangeWebToModel(id int, data dto.PriceChangeRequest) (model.PriceChange, error) {
+	res := model.PriceChange{
+		SubscriptionId: id,
+	}
+	if data.Price != nil {
+		res.Price = *data.Price
+	}
+	var err error
+	res.EffectiveFrom, err = dates.ParseMonth("effective_from", data.EffectiveFrom)
+	return res, err
+}
+
+func PriceChangeToWeb(data model.PriceChange) dto.PriceChangeResponce {
+	return dto.PriceChangeResponce{
+		Price:         data.Price,
+		EffectiveFrom: ConvertDateToString(data.EffectiveFrom),
+	}
+}
+
+func ExchangeRateWebToModel(data dto.ExchangeRateRequest) (model.ExchangeRate, error) {
+	month, err := dates.ParseMonth("month", data.Month)
+	return model.ExchangeRate{
+		Currency: data.Currency,
+		Month:    month,
+		Rate:     data.Rate,
+	}, err
+}
+
+func ExchangeRateToWeb(data model.ExchangeRate) dto.ExchangeRateResponce {
+	return dto.ExchangeRateResponce{
+		Currency: data.Currency,
+		Month:    ConvertDateToString(data.Month),
+		Rate:     data.Rate,
+	}
+}
+
+func CostRequestToCostDB(data dto.CostRequest) (dto.CostRequestToDB, error) {
+	res := dto.CostRequestToDB{
 		ServiceName: data.ServiceName,
 		UserId:      data.UserId,
-		StartDate:   ConvertStringToDate(data.StartDate),
-		EndDate:     ConvertStringToDate(data.EndDate),
 	}
+	var err error
+	res.StartDate, err = dates.ParseStart("start_date", data.StartDate)
+	if err != nil {
+		return res, err
+	}
+	res.EndDate, err = dates.ParseEnd("end_date", data.EndDate)
+	return res, err
 }
 
-func ConvertStringToDate(str string) (date time.Time) {
-	split := strings.Split(str, "-")
-	dateStr := fmt.Sprintf("%s-%s-01", split[1], split[0])
-	date, _ = time.Parse("2006-01-02", dateStr)
-	return date
+func LoadListWebToDB(data dto.LoadListRequest) (dto.LoadListRequestToDB, error) {
+	res := dto.LoadListRequestToDB{
+		Limit:         data.Limit,
+		Offset:        data.Offset,
+		UserId:        data.UserId,
+		ServiceName:   data.ServiceName,
+		ServicePrefix: data.ServicePrefix,
+		PriceMin:      data.PriceMin,
+		PriceMax:      data.PriceMax,
+		Sort:          data.Sort,
+		Order:         data.Order,
+	}
+	if data.ActiveMonth != "" {
+		month, err := dates.ParseMonth("active_month", data.ActiveMonth)
+		if err != nil {
+			return res, err
+		}
+		res.ActiveMonth = month
+	}
+	return res, nil
 }
 
 func ConvertDateToString(date time.Time) (str string) {
@@ -72,3 +212,31 @@ func ConvertDateToString(date time.Time) (str string) {
 	year, month, _ := date.Date()
 	return fmt.Sprintf("%02d-%d", month, year)
 }
+
+// ConvertDayToString formats a date as DD-MM-YYYY.
+func ConvertDayToString(date time.Time) (str string) {
+	if date.IsZero() {
+		return ""
+	}
+	year, month, day := date.Date()
+	return fmt.Sprintf("%02d-%02d-%d", day, month, year)
+}
+
+// ConvertStartToString formats a start date on the first day of a month
+// as MM-YYYY, like dates were formatted before day precision, and other
+// start dates as DD-MM-YYYY.
+func ConvertStartToString(date time.Time) string {
+	if date.Day() == 1 {
+		return ConvertDateToString(date)
+	}
+	return ConvertDayToString(date)
+}
+
+// ConvertEndToString formats an inclusive end date on the last day of a month
+// as MM-YYYY and other end dates as DD-MM-YYYY.
+func ConvertEndToString(date time.Time) string {
+	if date.AddDate(0, 0, 1).Day() == 1 {
+		return ConvertDateToString(date)
+	}
+	return ConvertDayToString(date)
+}
@@ -0,0 +1,129 @@
+// Package migrate applies the embedded goose migrations of a storage driver.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+
+	"github.com/hollisgr/subservice/internal/config"
+	"github.com/hollisgr/subservice/migrations"
+)
+
+// Commands of the migrate subcommand.
+const (
+	CommandUp     = "up"
+	CommandDown   = "down"
+	CommandStatus = "status"
+)
+
+var (
+	ErrUnknownCommand = errors.New("unknown migrate command, expected up, down or status")
+	ErrUnknownDriver  = errors.New("storage driver has no migrations")
+)
+
+// Run runs the command against the database of the storage driver: up applies
+// all pending migrations, down rolls back the last one and status writes the
+// state of every migration to out.
+func Run(ctx context.Context, driver string, conn *sql.DB, command string, out io.Writer) error {
+	provider, err := newProvider(driver, conn)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case CommandUp:
+		results, err := provider.Up(ctx)
+		for _, res := range results {
+			logResult(res)
+		}
+		if err != nil {
+			return fmt.Errorf("migrate up err: %w", err)
+		}
+		version, err := provider.GetDBVersion(ctx)
+		if err != nil {
+			return fmt.Errorf("migrate get version err: %w", err)
+		}
+		log.Println("Database migrated to version:", version)
+	case CommandDown:
+		res, err := provider.Down(ctx)
+		if res != nil {
+			logResult(res)
+		}
+		if err != nil {
+			return fmt.Errorf("migrate down err: %w", err)
+		}
+	case CommandStatus:
+		statuses, err := provider.Status(ctx)
+		if err != nil {
+			return fmt.Errorf("migrate status err: %w", err)
+		}
+		return writeStatus(out, statuses)
+	default:
+		return ErrUnknownCommand
+	}
+	return nil
+}
+
+// newProvider returns the goose provider of the driver migrations. Postgres
+// migrations run under a session advisory lock, so replicas starting at once
+// apply them one after another. SQLite is single-node and needs no lock.
+func newProvider(driver string, conn *sql.DB) (*goose.Provider, error) {
+	var (
+		dialect goose.Dialect
+		fsys    fs.FS
+		options []goose.ProviderOption
+	)
+
+	switch driver {
+	case config.DriverPostgres:
+		locker, err := lock.NewPostgresSessionLocker()
+		if err != nil {
+			return nil, fmt.Errorf("migrate create locker err: %w", err)
+		}
+		dialect = goose.DialectPostgres
+		fsys = migrations.Postgres
+		options = append(options, goose.WithSessionLocker(locker))
+	case config.DriverSQLite:
+		dialect = goose.DialectSQLite3
+		fsys = migrations.SQLite
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDriver, driver)
+	}
+
+	provider, err := goose.NewProvider(dialect, conn, fsys, options...)
+	if err != nil {
+		return nil, fmt.Errorf("migrate create provider err: %w", err)
+	}
+	return provider, nil
+}
+
+func logResult(res *goose.MigrationResult) {
+	if res.Error != nil {
+		log.Printf("Migration %s %s failed: %v", res.Direction, res.Source.Path, res.Error)
+		return
+	}
+	log.Printf("Migration %s %s OK (%s)", res.Direction, res.Source.Path, res.Duration.Round(time.Millisecond))
+}
+
+func writeStatus(out io.Writer, statuses []*goose.MigrationStatus) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "APPLIED AT\tMIGRATION")
+	for _, status := range statuses {
+		appliedAt := "Pending"
+		if status.State == goose.StateApplied {
+			appliedAt = status.AppliedAt.UTC().Format(time.DateTime)
+		}
+		fmt.Fprintf(w, "%s\t%s\n", appliedAt, status.Source.Path)
+	}
+	return w.Flush()
+}
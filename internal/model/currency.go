@@ -0,0 +1,34 @@
+package model
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// BaseCurrency is the currency exchange rates are expressed in.
+const BaseCurrency = "RUB"
+
+// MinorUnits is the number of decimal places money amounts are rounded to.
+const MinorUnits = 2
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// IsCurrency reports whether code looks like an ISO 4217 currency code.
+func IsCurrency(code string) bool {
+	return currencyCode.MatchString(code)
+}
+
+// IsPrice reports whether price is a non-negative amount in minor units.
+func IsPrice(price decimal.Decimal) bool {
+	return !price.IsNegative() && price.Equal(price.Round(MinorUnits))
+}
+
+// ExchangeRate is the amount of BaseCurrency for one unit of Currency,
+// it applies from Month on until a later rate is set.
+type ExchangeRate struct {
+	Currency string          `json:"currency" db:"currency"`
+	Month    time.Time       `json:"month" db:"month"`
+	Rate     decimal.Decimal `json:"rate" db:"rate"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+	ActionPurge   = "purge"
+	ActionPrice   = "price"
+)
+
+// HistoryEntry is an audit record of one subscription change with the
+// snapshots taken before and after it, Before is nil on create and restore,
+// After is nil on delete and purge. PriceChange is set on price changes only.
+type HistoryEntry struct {
+	Id             int64         `json:"id" db:"id"`
+	SubscriptionId int           `json:"subscription_id" db:"subscription_id"`
+	Action         string        `json:"action" db:"action"`
+	Actor          string        `json:"actor" db:"actor"`
+	ChangedAt      time.Time     `json:"changed_at" db:"changed_at"`
+	Before         *Subscription `json:"before" db:"before"`
+	After          *Subscription `json:"after" db:"after"`
+	PriceChange    *PriceChange  `json:"price_change" db:"price_change"`
+}
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// PriceChange is a subscription price that applies from EffectiveFrom month on,
+// before the first change the subscription price itself applies.
+type PriceChange struct {
+	Id             int             `json:"id" db:"id"`
+	SubscriptionId int             `json:"subscription_id" db:"subscription_id"`
+	EffectiveFrom  time.Time       `json:"effective_from" db:"effective_from"`
+	Price          decimal.Decimal `json:"price" db:"price"`
+}
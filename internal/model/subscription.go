@@ -4,13 +4,29 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
+// Subscription is a stored subscription, a nil EndDate means it is open-ended.
+// Price is in Currency. Version is incremented on every change and guards
+// concurrent modifications.
 type Subscription struct {
-	Id          int       `json:"id" db:"id"`
-	ServiceName string    `json:"service_name" db:"service_name"`
-	Price       uint      `json:"price" db:"price"`
-	UserId      uuid.UUID `json:"user_id" db:"user_id"`
-	StartDate   time.Time `json:"start_date" db:"start_date"`
-	EndDate     time.Time `json:"end_date" db:"end_date"`
+	Id            int             `json:"id" db:"id"`
+	ServiceName   string          `json:"service_name" db:"service_name"`
+	Price         decimal.Decimal `json:"price" db:"price"`
+	UserId        uuid.UUID       `json:"user_id" db:"user_id"`
+	StartDate     time.Time       `json:"start_date" db:"start_date"`
+	EndDate       *time.Time      `json:"end_date" db:"end_date"`
+	BillingPeriod string          `json:"billing_period" db:"billing_period"`
+	Currency      string          `json:"currency" db:"currency"`
+	Version       int             `json:"version" db:"version"`
 }
+
+// Billing periods, a subscription is charged at its start date and then
+// once every period while it is active.
+const (
+	PeriodWeekly    = "weekly"
+	PeriodMonthly   = "monthly"
+	PeriodQuarterly = "quarterly"
+	PeriodAnnual    = "annual"
+)
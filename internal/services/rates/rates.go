@@ -0,0 +1,157 @@
+package rates
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"github.com/hollisgr/subservice/internal/domain"
+	"github.com/hollisgr/subservice/internal/dto"
+	"github.com/hollisgr/subservice/internal/interfaces"
+	"github.com/hollisgr/subservice/internal/mappers"
+	"github.com/hollisgr/subservice/internal/model"
+)
+
+var (
+	ErrIncorrectCurrency = domain.Validation("currency", "currency", "currency must be a three letter ISO 4217 code other than base currency")
+	ErrIncorrectRate     = domain.Validation("rate", "rate", "rate must be a positive number")
+	ErrIncorrectCSV      = domain.Validation("file", "csv", "incorrect csv")
+)
+
+type rates struct {
+	storage interfaces.Storage
+}
+
+func New(s interfaces.Storage) interfaces.Rates {
+	return &rates{
+		storage: s,
+	}
+}
+
+// Set stores the exchange rate of the currency from the given month on.
+func (r *rates) Set(ctx context.Context, data dto.ExchangeRateRequest) error {
+	logrus.Info("rates service: set")
+
+	rate, err := convertRate(data)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+
+	err = r.storage.SetRates(ctx, []model.ExchangeRate{rate})
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	logrus.Info("rates service: set success")
+	return nil
+}
+
+// Import stores the rates of a currency,month,rate CSV, the header row is optional.
+// Nothing is stored if any row is incorrect.
+func (r *rates) Import(ctx context.Context, reader io.Reader) (int, error) {
+	logrus.Info("rates service: import")
+
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = 3
+	csvReader.TrimLeadingSpace = true
+
+	var list []model.ExchangeRate
+	for line := 1; ; line++ {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			err = fmt.Errorf("%w: %v", ErrIncorrectCSV, err)
+			logrus.Error(err)
+			return 0, err
+		}
+
+		// первая строка может быть заголовком
+		if line == 1 && strings.EqualFold(record[0], "currency") {
+			continue
+		}
+
+		rate, err := decimal.NewFromString(record[2])
+		if err != nil {
+			err = fmt.Errorf("%w: line %d: %v", ErrIncorrectRate, line, err)
+			logrus.Error(err)
+			return 0, err
+		}
+		data := dto.ExchangeRateRequest{
+			Currency: strings.ToUpper(record[0]),
+			Month:    record[1],
+			Rate:     rate,
+		}
+		converted, err := convertRate(data)
+		if err != nil {
+			err = fmt.Errorf("%w: line %d", err, line)
+			logrus.Error(err)
+			return 0, err
+		}
+		list = append(list, converted)
+	}
+
+	if len(list) == 0 {
+		err := fmt.Errorf("%w: no rates", ErrIncorrectCSV)
+		logrus.Error(err)
+		return 0, err
+	}
+
+	err := r.storage.SetRates(ctx, list)
+	if err != nil {
+		logrus.Error(err)
+		return 0, err
+	}
+	logrus.Infof("rates service: import success, stored %d", len(list))
+	return len(list), nil
+}
+
+// List returns the exchange rates ordered by currency and month,
+// an empty currency means all of them.
+func (r *rates) List(ctx context.Context, currency string) ([]dto.ExchangeRateResponce, error) {
+	logrus.Info("rates service: list")
+	res := []dto.ExchangeRateResponce{}
+
+	var currencies []string
+	if currency != "" {
+		if !model.IsCurrency(currency) {
+			logrus.Error(ErrIncorrectCurrency)
+			return res, ErrIncorrectCurrency
+		}
+		currencies = []string{currency}
+	}
+
+	// курсы, заданные на будущее, тоже показываем
+	list, err := r.storage.Rates(ctx, currencies, time.Date(9999, 12, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		logrus.Error(err)
+		return res, err
+	}
+
+	for _, rate := range list {
+		res = append(res, mappers.ExchangeRateToWeb(rate))
+	}
+	logrus.Info("rates service: list success")
+	return res, nil
+}
+
+// convertRate validates the exchange rate and converts it to the model.
+func convertRate(data dto.ExchangeRateRequest) (model.ExchangeRate, error) {
+	if !model.IsCurrency(data.Currency) || data.Currency == model.BaseCurrency {
+		return model.ExchangeRate{}, ErrIncorrectCurrency
+	}
+
+	if !data.Rate.IsPositive() {
+		return model.ExchangeRate{}, ErrIncorrectRate
+	}
+
+	return mappers.ExchangeRateWebToModel(data)
+}
@@ -0,0 +1,117 @@
+package subscriptions
+
+import (
+	"time"
+
+	"github.com/hollisgr/subservice/internal/model"
+)
+
+// billingPeriods maps a billing period to the years, months and days between charges.
+var billingPeriods = map[string][3]int{
+	model.PeriodWeekly:    {0, 0, 7},
+	model.PeriodMonthly:   {0, 1, 0},
+	model.PeriodQuarterly: {0, 3, 0},
+	model.PeriodAnnual:    {1, 0, 0},
+}
+
+// billingCycle is a billing period [start, end) paid by the charge at start,
+// [from, to) is the part of it the subscription is active within the requested window.
+type billingCycle struct {
+	start, end time.Time
+	from, to   time.Time
+}
+
+// days returns the number of days in the cycle and in its active part.
+func (c billingCycle) days() (total, active int64) {
+	return daysBetween(c.start, c.end), daysBetween(c.from, c.to)
+}
+
+// billingCycles returns the billing cycles of the subscription that overlap
+// [from, to). The subscription is charged at its start date and then every
+// billing period until its end date inclusive.
+func billingCycles(sub model.Subscription, from, to time.Time) []billingCycle {
+	step, ok := billingPeriods[sub.BillingPeriod]
+	if !ok {
+		step = billingPeriods[model.PeriodMonthly]
+	}
+
+	// дата окончания включается целиком
+	if sub.EndDate != nil && sub.EndDate.AddDate(0, 0, 1).Before(to) {
+		to = sub.EndDate.AddDate(0, 0, 1)
+	}
+	if sub.StartDate.After(from) {
+		from = sub.StartDate
+	}
+
+	var res []billingCycle
+	for i := firstCycle(sub.StartDate, step, from); ; i++ {
+		start := chargeDate(sub.StartDate, step, i)
+		if !start.Before(to) {
+			break
+		}
+		end := chargeDate(sub.StartDate, step, i+1)
+		if !end.After(from) {
+			continue
+		}
+		cycle := billingCycle{start: start, end: end, from: start, to: end}
+		if cycle.from.Before(from) {
+			cycle.from = from
+		}
+		if cycle.to.After(to) {
+			cycle.to = to
+		}
+		res = append(res, cycle)
+	}
+	return res
+}
+
+// firstCycle returns the number of the last charge on or before from, so the
+// cycles before the window are skipped without walking them from the start date.
+func firstCycle(start time.Time, step [3]int, from time.Time) int {
+	if !from.After(start) {
+		return 0
+	}
+
+	n := 0
+	if step[2] != 0 {
+		n = int(daysBetween(start, from) / int64(step[2]))
+	} else {
+		months := (from.Year()-start.Year())*12 + int(from.Month()) - int(start.Month())
+		n = months / (step[0]*12 + step[1])
+	}
+	// из-за дня месяца оценка может быть на период позже
+	for n > 0 && chargeDate(start, step, n).After(from) {
+		n--
+	}
+	return n
+}
+
+// billingEvents returns the dates the subscription is charged at within [from, to).
+func billingEvents(sub model.Subscription, from, to time.Time) []time.Time {
+	var res []time.Time
+	for _, cycle := range billingCycles(sub, from, to) {
+		if !cycle.start.Before(from) {
+			res = append(res, cycle.start)
+		}
+	}
+	return res
+}
+
+// chargeDate returns the date of the n-th charge after start. Monthly steps
+// keep the day of start, falling back to the last day of shorter months.
+func chargeDate(start time.Time, step [3]int, n int) time.Time {
+	// считаем от даты начала, чтобы не накапливать сдвиг по дням
+	if step[2] != 0 {
+		return start.AddDate(step[0]*n, step[1]*n, step[2]*n)
+	}
+	month := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, start.Location()).AddDate(step[0]*n, step[1]*n, 0)
+	day := start.Day()
+	if last := month.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return month.AddDate(0, 0, day-1)
+}
+
+func daysBetween(from, to time.Time) int64 {
+	return int64(to.Sub(from).Round(time.Hour).Hours()) / 24
+}
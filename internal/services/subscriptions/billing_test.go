@@ -0,0 +1,184 @@
+package subscriptions
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hollisgr/subservice/internal/model"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func subscription(period string, start time.Time, end *time.Time) model.Subscription {
+	return model.Subscription{
+		Id:            1,
+		BillingPeriod: period,
+		StartDate:     start,
+		EndDate:       end,
+		Currency:      model.BaseCurrency,
+	}
+}
+
+func TestBillingEvents(t *testing.T) {
+	weeklyEnd := date(2025, 1, 20)
+	monthlyEnd := date(2025, 3, 10)
+
+	tests := []struct {
+		name     string
+		sub      model.Subscription
+		from, to time.Time
+		want     []time.Time
+	}{
+		{
+			name: "monthly from the 31st",
+			sub:  subscription(model.PeriodMonthly, date(2025, 1, 31), nil),
+			from: date(2025, 1, 1),
+			to:   date(2025, 7, 1),
+			want: []time.Time{
+				date(2025, 1, 31), date(2025, 2, 28), date(2025, 3, 31),
+				date(2025, 4, 30), date(2025, 5, 31), date(2025, 6, 30),
+			},
+		},
+		{
+			name: "monthly from the 31st in a leap year",
+			sub:  subscription(model.PeriodMonthly, date(2024, 1, 31), nil),
+			from: date(2024, 2, 1),
+			to:   date(2024, 4, 1),
+			want: []time.Time{date(2024, 2, 29), date(2024, 3, 31)},
+		},
+		{
+			name: "annual from 29 Feb",
+			sub:  subscription(model.PeriodAnnual, date(2024, 2, 29), nil),
+			from: date(2024, 1, 1),
+			to:   date(2029, 1, 1),
+			want: []time.Time{
+				date(2024, 2, 29), date(2025, 2, 28), date(2026, 2, 28),
+				date(2027, 2, 28), date(2028, 2, 29),
+			},
+		},
+		{
+			name: "weekly with end date",
+			sub:  subscription(model.PeriodWeekly, date(2025, 1, 1), &weeklyEnd),
+			from: date(2025, 1, 1),
+			to:   date(2025, 2, 1),
+			want: []time.Time{date(2025, 1, 1), date(2025, 1, 8), date(2025, 1, 15)},
+		},
+		{
+			name: "monthly charged on the end date",
+			sub:  subscription(model.PeriodMonthly, date(2025, 1, 10), &monthlyEnd),
+			from: date(2025, 1, 1),
+			to:   date(2025, 12, 1),
+			want: []time.Time{date(2025, 1, 10), date(2025, 2, 10), date(2025, 3, 10)},
+		},
+		{
+			name: "quarterly charged before the window",
+			sub:  subscription(model.PeriodQuarterly, date(2024, 11, 15), nil),
+			from: date(2025, 1, 1),
+			to:   date(2025, 4, 1),
+			want: []time.Time{date(2025, 2, 15)},
+		},
+		{
+			name: "quarterly without charges in the window",
+			sub:  subscription(model.PeriodQuarterly, date(2024, 11, 15), nil),
+			from: date(2024, 12, 1),
+			to:   date(2025, 2, 1),
+			want: nil,
+		},
+		{
+			name: "starts after the window",
+			sub:  subscription(model.PeriodMonthly, date(2025, 5, 1), nil),
+			from: date(2025, 1, 1),
+			to:   date(2025, 5, 1),
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := billingEvents(tt.sub, tt.from, tt.to)
+			if !slices.EqualFunc(got, tt.want, time.Time.Equal) {
+				t.Errorf("billingEvents = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBillingCycles(t *testing.T) {
+	end := date(2025, 2, 9)
+
+	tests := []struct {
+		name     string
+		sub      model.Subscription
+		from, to time.Time
+		want     []billingCycle
+	}{
+		{
+			name: "cut by the window",
+			sub:  subscription(model.PeriodMonthly, date(2025, 1, 15), nil),
+			from: date(2025, 2, 1),
+			to:   date(2025, 3, 1),
+			want: []billingCycle{
+				{start: date(2025, 1, 15), end: date(2025, 2, 15), from: date(2025, 2, 1), to: date(2025, 2, 15)},
+				{start: date(2025, 2, 15), end: date(2025, 3, 15), from: date(2025, 2, 15), to: date(2025, 3, 1)},
+			},
+		},
+		{
+			name: "cut by the end date",
+			sub:  subscription(model.PeriodMonthly, date(2025, 1, 15), &end),
+			from: date(2025, 1, 1),
+			to:   date(2025, 3, 1),
+			want: []billingCycle{
+				{start: date(2025, 1, 15), end: date(2025, 2, 15), from: date(2025, 1, 15), to: date(2025, 2, 10)},
+			},
+		},
+		{
+			name: "quarterly charged before the window",
+			sub:  subscription(model.PeriodQuarterly, date(2024, 11, 15), nil),
+			from: date(2024, 12, 1),
+			to:   date(2025, 1, 1),
+			want: []billingCycle{
+				{start: date(2024, 11, 15), end: date(2025, 2, 15), from: date(2024, 12, 1), to: date(2025, 1, 1)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := billingCycles(tt.sub, tt.from, tt.to)
+			equal := func(a, b billingCycle) bool {
+				return a.start.Equal(b.start) && a.end.Equal(b.end) && a.from.Equal(b.from) && a.to.Equal(b.to)
+			}
+			if !slices.EqualFunc(got, tt.want, equal) {
+				t.Errorf("billingCycles = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFirstCycle(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	periods := []string{model.PeriodWeekly, model.PeriodMonthly, model.PeriodQuarterly, model.PeriodAnnual}
+
+	for range 20000 {
+		period := periods[rnd.Intn(len(periods))]
+		step := billingPeriods[period]
+		start := date(2020+rnd.Intn(5), time.Month(1+rnd.Intn(12)), 1+rnd.Intn(31))
+		from := start.AddDate(0, 0, rnd.Intn(3000)-100)
+
+		// номер последнего списания не позже from обходом от даты начала
+		want := 0
+		if from.After(start) {
+			for !chargeDate(start, step, want+1).After(from) {
+				want++
+			}
+		}
+
+		got := firstCycle(start, step, from)
+		if got != want {
+			t.Fatalf("firstCycle(%s, %s, %s) = %d, want %d",
+				start.Format(time.DateOnly), period, from.Format(time.DateOnly), got, want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package subscriptions
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+
+	"github.com/hollisgr/subservice/internal/domain"
+	"github.com/hollisgr/subservice/internal/dto"
+	"github.com/hollisgr/subservice/internal/model"
+)
+
+var ErrIncorrectCursor = domain.Validation("cursor", "cursor", "incorrect cursor")
+
+// cursor is the decoded form of the opaque next_cursor value. It remembers
+// the sort it was issued for, so it can't be reused with another ordering.
+type cursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Value string `json:"v"`
+	Id    int    `json:"id"`
+}
+
+// encodeCursor builds the cursor pointing right after the given subscription.
+func encodeCursor(sort, order string, last model.Subscription) string {
+	c := cursor{
+		Sort:  sort,
+		Order: order,
+		Value: cursorValue(sort, last),
+		Id:    last.Id,
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses the cursor and checks it was issued for the same sort.
+func decodeCursor(str, sort, order string) (dto.ListCursor, error) {
+	c := cursor{}
+	data, err := base64.RawURLEncoding.DecodeString(str)
+	if err != nil {
+		return dto.ListCursor{}, ErrIncorrectCursor
+	}
+
+	err = json.Unmarshal(data, &c)
+	if err != nil || c.Sort != sort || c.Order != order {
+		return dto.ListCursor{}, ErrIncorrectCursor
+	}
+
+	return dto.ListCursor{Value: c.Value, Id: c.Id}, nil
+}
+
+// cursorValue returns the value of the sort column as the storage expects it.
+func cursorValue(sort string, sub model.Subscription) string {
+	switch sort {
+	case "price":
+		return sub.Price.String()
+	case "start_date":
+		return sub.StartDate.Format("2006-01-02")
+	case "end_date":
+		if sub.EndDate == nil {
+			return "infinity"
+		}
+		return sub.EndDate.Format("2006-01-02")
+	case "service_name":
+		return sub.ServiceName
+	default:
+		return strconv.Itoa(sub.Id)
+	}
+}
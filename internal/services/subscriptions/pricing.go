@@ -0,0 +1,209 @@
+package subscriptions
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/hollisgr/subservice/internal/domain"
+	"github.com/hollisgr/subservice/internal/model"
+)
+
+var (
+	ErrPriceRequired           = domain.Validation("price", "required", "price is required")
+	ErrIncorrectPrice          = domain.Validation("price", "money", "price must be a non-negative amount with at most two decimal places")
+	ErrIncorrectCurrency       = domain.Validation("currency", "currency", "currency must be a three letter ISO 4217 code")
+	ErrIncorrectTargetCurrency = domain.Validation("target_currency", "currency", "target currency must be a three letter ISO 4217 code")
+	ErrNoExchangeRate          = domain.Unprocessable("no exchange rate")
+
+	// ErrPriceScheduled is returned when PUT or PATCH changes the price of a subscription
+	// with price changes, they would override the new price in cost calculation.
+	ErrPriceScheduled = domain.Conflict("subscription has price changes, change its price via POST /subscription/{id}/price")
+)
+
+// pricing holds what is needed to price subscription charges in the target currency.
+type pricing struct {
+	prices map[int][]model.PriceChange
+	rates  map[string][]model.ExchangeRate
+	target string
+}
+
+// loadPricing loads the price changes of the subscriptions and the exchange
+// rates set up to the given date for their currencies and the target one.
+func (s *sub) loadPricing(ctx context.Context, subs []model.Subscription, target string, to time.Time) (pricing, error) {
+	res := pricing{
+		prices: make(map[int][]model.PriceChange, len(subs)),
+		rates:  map[string][]model.ExchangeRate{},
+		target: target,
+	}
+
+	ids := make([]int, 0, len(subs))
+	currencies := []string{}
+	seen := map[string]bool{model.BaseCurrency: true}
+	if !seen[target] {
+		seen[target] = true
+		currencies = append(currencies, target)
+	}
+	for _, sub := range subs {
+		ids = append(ids, sub.Id)
+		if !seen[sub.Currency] {
+			seen[sub.Currency] = true
+			currencies = append(currencies, sub.Currency)
+		}
+	}
+
+	changes, err := s.storage.Prices(ctx, ids)
+	if err != nil {
+		return res, err
+	}
+	for _, change := range changes {
+		res.prices[change.SubscriptionId] = append(res.prices[change.SubscriptionId], change)
+	}
+
+	// все подписки в базовой валюте, курсы не нужны
+	if len(currencies) == 0 {
+		return res, nil
+	}
+
+	rates, err := s.storage.Rates(ctx, currencies, to)
+	if err != nil {
+		return res, err
+	}
+	for _, rate := range rates {
+		res.rates[rate.Currency] = append(res.rates[rate.Currency], rate)
+	}
+	return res, nil
+}
+
+// cost returns the cost of the subscription charges within [from, to) and
+// their count. With prorate the cycles cut by the window or by the
+// subscription start and end are charged only for their active days.
+func (p pricing) cost(sub model.Subscription, from, to time.Time, prorate bool) (decimal.Decimal, int, error) {
+	cost := decimal.Zero
+	if !prorate {
+		events := billingEvents(sub, from, to)
+		for _, date := range events {
+			amount, err := p.charge(sub, date)
+			if err != nil {
+				return decimal.Zero, 0, err
+			}
+			cost = cost.Add(amount)
+		}
+		return cost, len(events), nil
+	}
+
+	cycles := billingCycles(sub, from, to)
+	for _, cycle := range cycles {
+		amount, err := p.charge(sub, cycle.start)
+		if err != nil {
+			return decimal.Zero, 0, err
+		}
+		total, active := cycle.days()
+		if active != total {
+			amount = amount.Mul(decimal.NewFromInt(active)).DivRound(decimal.NewFromInt(total), model.MinorUnits)
+		}
+		cost = cost.Add(amount)
+	}
+	return cost, len(cycles), nil
+}
+
+// charge returns the amount of a subscription charge at the given date
+// converted to the target currency and rounded to minor units.
+func (p pricing) charge(sub model.Subscription, date time.Time) (decimal.Decimal, error) {
+	price := priceAt(sub, p.prices[sub.Id], date)
+
+	currency := sub.Currency
+	if currency == "" {
+		currency = model.BaseCurrency
+	}
+	if currency == p.target {
+		return price, nil
+	}
+
+	from, err := p.rateAt(currency, date)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	to, err := p.rateAt(p.target, date)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+
+	// переводим через базовую валюту, округляем до копеек
+	return price.Mul(from).DivRound(to, model.MinorUnits), nil
+}
+
+// rateAt returns the latest rate of the currency set on or before the date,
+// rates must be ordered by month.
+func (p pricing) rateAt(currency string, date time.Time) (decimal.Decimal, error) {
+	if currency == model.BaseCurrency {
+		return decimal.NewFromInt(1), nil
+	}
+
+	var rate *model.ExchangeRate
+	for i, r := range p.rates[currency] {
+		if r.Month.After(date) {
+			break
+		}
+		rate = &p.rates[currency][i]
+	}
+	if rate == nil || rate.Rate.IsZero() {
+		return decimal.Decimal{}, fmt.Errorf("%w for %s at %s", ErrNoExchangeRate, currency, date.Format("01-2006"))
+	}
+	return rate.Rate, nil
+}
+
+// priceAt returns the subscription price in effect at the given date,
+// changes must be ordered by effective date.
+func priceAt(sub model.Subscription, changes []model.PriceChange, date time.Time) decimal.Decimal {
+	price := sub.Price
+	for _, change := range changes {
+		if change.EffectiveFrom.After(date) {
+			break
+		}
+		price = change.Price
+	}
+	return price
+}
+
+// currentPrices returns the prices of the subscriptions in effect today by subscription id.
+func (s *sub) currentPrices(ctx context.Context, subs []model.Subscription) (map[int]decimal.Decimal, error) {
+	ids := make([]int, 0, len(subs))
+	for _, sub := range subs {
+		ids = append(ids, sub.Id)
+	}
+
+	changes, err := s.storage.Prices(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[int][]model.PriceChange, len(subs))
+	for _, change := range changes {
+		byId[change.SubscriptionId] = append(byId[change.SubscriptionId], change)
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	res := make(map[int]decimal.Decimal, len(subs))
+	for _, sub := range subs {
+		res[sub.Id] = priceAt(sub, byId[sub.Id], today)
+	}
+	return res, nil
+}
+
+// checkPriceEdit rejects a new price of the subscription if it has price changes.
+func (s *sub) checkPriceEdit(ctx context.Context, current model.Subscription, price decimal.Decimal) error {
+	if price.Equal(current.Price) {
+		return nil
+	}
+
+	changes, err := s.storage.Prices(ctx, []int{current.Id})
+	if err != nil {
+		return err
+	}
+	if len(changes) != 0 {
+		return ErrPriceScheduled
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package subscriptions
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/hollisgr/subservice/internal/model"
+)
+
+func priced(sub model.Subscription, price, currency string) model.Subscription {
+	sub.Price = decimal.RequireFromString(price)
+	sub.Currency = currency
+	return sub
+}
+
+func TestPricingCost(t *testing.T) {
+	usdRates := []model.ExchangeRate{
+		{Currency: "USD", Month: date(2025, 1, 1), Rate: decimal.RequireFromString("90")},
+		{Currency: "USD", Month: date(2025, 3, 1), Rate: decimal.RequireFromString("100")},
+	}
+	changes := []model.PriceChange{
+		{SubscriptionId: 1, EffectiveFrom: date(2025, 3, 1), Price: decimal.RequireFromString("200")},
+	}
+
+	tests := []struct {
+		name        string
+		sub         model.Subscription
+		prices      []model.PriceChange
+		rates       []model.ExchangeRate
+		target      string
+		from, to    time.Time
+		prorate     bool
+		wantCost    string
+		wantCharges int
+	}{
+		{
+			name:        "charges in the window",
+			sub:         priced(subscription(model.PeriodMonthly, date(2025, 1, 15), nil), "100", "RUB"),
+			target:      "RUB",
+			from:        date(2025, 2, 1),
+			to:          date(2025, 3, 1),
+			wantCost:    "100",
+			wantCharges: 1,
+		},
+		{
+			name:        "prorated cycles cut by the window",
+			sub:         priced(subscription(model.PeriodMonthly, date(2025, 1, 15), nil), "100", "RUB"),
+			target:      "RUB",
+			from:        date(2025, 2, 1),
+			to:          date(2025, 3, 1),
+			prorate:     true,
+			wantCost:    "95.16", // 100 * 14/31 + 100 * 14/28
+			wantCharges: 2,
+		},
+		{
+			name:        "prorate keeps whole cycles",
+			sub:         priced(subscription(model.PeriodMonthly, date(2025, 1, 1), nil), "100", "RUB"),
+			target:      "RUB",
+			from:        date(2025, 1, 1),
+			to:          date(2025, 4, 1),
+			prorate:     true,
+			wantCost:    "300",
+			wantCharges: 3,
+		},
+		{
+			name:        "quarterly charged before the window",
+			sub:         priced(subscription(model.PeriodQuarterly, date(2024, 11, 15), nil), "300", "RUB"),
+			target:      "RUB",
+			from:        date(2024, 12, 1),
+			to:          date(2025, 1, 1),
+			wantCost:    "0",
+			wantCharges: 0,
+		},
+		{
+			name:        "prorated quarterly charged before the window",
+			sub:         priced(subscription(model.PeriodQuarterly, date(2024, 11, 15), nil), "300", "RUB"),
+			target:      "RUB",
+			from:        date(2024, 12, 1),
+			to:          date(2025, 1, 1),
+			prorate:     true,
+			wantCost:    "101.09", // 300 * 31/92
+			wantCharges: 1,
+		},
+		{
+			name:        "price change",
+			sub:         priced(subscription(model.PeriodMonthly, date(2025, 1, 1), nil), "100", "RUB"),
+			prices:      changes,
+			target:      "RUB",
+			from:        date(2025, 1, 1),
+			to:          date(2025, 5, 1),
+			wantCost:    "600",
+			wantCharges: 4,
+		},
+		{
+			name:        "converted to the base currency at the rate of every charge",
+			sub:         priced(subscription(model.PeriodMonthly, date(2025, 1, 1), nil), "10", "USD"),
+			rates:       usdRates,
+			target:      "RUB",
+			from:        date(2025, 1, 1),
+			to:          date(2025, 5, 1),
+			wantCost:    "3800",
+			wantCharges: 4,
+		},
+		{
+			name:        "converted from the base currency",
+			sub:         priced(subscription(model.PeriodMonthly, date(2025, 1, 1), nil), "100", "RUB"),
+			rates:       usdRates,
+			target:      "USD",
+			from:        date(2025, 1, 1),
+			to:          date(2025, 2, 1),
+			wantCost:    "1.11",
+			wantCharges: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := pricing{
+				prices: map[int][]model.PriceChange{tt.sub.Id: tt.prices},
+				rates:  map[string][]model.ExchangeRate{"USD": tt.rates},
+				target: tt.target,
+			}
+			cost, charges, err := p.cost(tt.sub, tt.from, tt.to, tt.prorate)
+			if err != nil {
+				t.Fatalf("cost: %v", err)
+			}
+			if !cost.Equal(decimal.RequireFromString(tt.wantCost)) || charges != tt.wantCharges {
+				t.Errorf("cost = %s, %d charges, want %s, %d charges", cost, charges, tt.wantCost, tt.wantCharges)
+			}
+		})
+	}
+}
+
+func TestPricingCostWithoutRate(t *testing.T) {
+	p := pricing{
+		rates: map[string][]model.ExchangeRate{
+			"USD": {{Currency: "USD", Month: date(2025, 3, 1), Rate: decimal.RequireFromString("100")}},
+		},
+		target: "RUB",
+	}
+	sub := priced(subscription(model.PeriodMonthly, date(2025, 1, 1), nil), "10", "USD")
+
+	_, _, err := p.cost(sub, date(2025, 1, 1), date(2025, 4, 1), false)
+	if !errors.Is(err, ErrNoExchangeRate) {
+		t.Errorf("cost returned %v, want %v", err, ErrNoExchangeRate)
+	}
+}
+
+func TestPriceAt(t *testing.T) {
+	sub := priced(subscription(model.PeriodMonthly, date(2025, 1, 1), nil), "100", "RUB")
+	changes := []model.PriceChange{
+		{EffectiveFrom: date(2025, 3, 1), Price: decimal.RequireFromString("200")},
+		{EffectiveFrom: date(2025, 6, 15), Price: decimal.RequireFromString("150")},
+	}
+
+	tests := []struct {
+		date time.Time
+		want string
+	}{
+		{date(2025, 1, 1), "100"},
+		{date(2025, 2, 28), "100"},
+		{date(2025, 3, 1), "200"},
+		{date(2025, 6, 14), "200"},
+		{date(2025, 6, 15), "150"},
+		{date(2026, 1, 1), "150"},
+	}
+	for _, tt := range tests {
+		got := priceAt(sub, changes, tt.date)
+		if !got.Equal(decimal.RequireFromString(tt.want)) {
+			t.Errorf("priceAt(%s) = %s, want %s", tt.date.Format(time.DateOnly), got, tt.want)
+		}
+	}
+}
@@ -2,22 +2,37 @@ package subscriptions
 
 import (
 	"context"
-	"errors"
-	"fmt"
-	"main/internal/dto"
-	"main/internal/interfaces"
-	"main/internal/mappers"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
+
+	"github.com/hollisgr/subservice/internal/domain"
+	"github.com/hollisgr/subservice/internal/dto"
+	"github.com/hollisgr/subservice/internal/interfaces"
+	"github.com/hollisgr/subservice/internal/mappers"
+	"github.com/hollisgr/subservice/internal/model"
 )
 
 var (
-	ErrEndIsLess      = errors.New("end date is less than start date")
-	ErrIncorrectDate  = errors.New("start or end date is incorrect")
-	ErrIncorrectValue = errors.New("incorrect value")
+	ErrEndIsLess      = domain.Validation("end_date", "end_before_start", "end date is less than start date")
+	ErrIncorrectValue = domain.Validation("", "value", "limit or offset is less than 0")
+	ErrPriceRange     = domain.Validation("price_max", "price_range", "price_min is greater than price_max")
+	ErrIncorrectSort  = domain.Validation("sort", "oneof", "incorrect sort field or order")
+	ErrOutOfPeriod    = domain.Validation("effective_from", "out_of_period", "effective date is outside of subscription period")
+	ErrBillingPeriod  = domain.Validation("billing_period", "oneof", "billing period must be weekly, monthly, quarterly or annual")
 )
 
+// sortFields lists the fields a subscription list can be sorted by, empty means by id.
+var sortFields = map[string]bool{
+	"":             true,
+	"id":           true,
+	"price":        true,
+	"start_date":   true,
+	"end_date":     true,
+	"service_name": true,
+}
+
 type sub struct {
 	storage interfaces.Storage
 }
@@ -33,34 +48,47 @@ func (s *sub) Create(ctx context.Context, data dto.CreateSubRequest) (int, error
 
 	logrus.Info("sub service: create")
 
-	ok := checkDateStr(data.StartDate)
+	if data.Price == nil {
+		logrus.Error(ErrPriceRequired)
+		return id, ErrPriceRequired
+	}
 
-	if !ok {
-		logrus.Error(ErrIncorrectDate)
-		return id, ErrIncorrectDate
+	if !model.IsPrice(*data.Price) {
+		logrus.Error(ErrIncorrectPrice)
+		return id, ErrIncorrectPrice
 	}
 
-	// если дату окончания не дали, будем считать что дата окончания конец столетия
+	if data.BillingPeriod == "" {
+		data.BillingPeriod = model.PeriodMonthly
+	}
 
-	if data.EndDate == "" {
-		data.EndDate = "12-2099"
+	if !checkBillingPeriod(data.BillingPeriod) {
+		logrus.Error(ErrBillingPeriod)
+		return id, ErrBillingPeriod
 	}
 
-	ok = checkDateStr(data.EndDate)
+	if data.Currency == "" {
+		data.Currency = model.BaseCurrency
+	}
 
-	if !ok {
-		logrus.Error(ErrIncorrectDate)
-		return id, ErrIncorrectDate
+	if !model.IsCurrency(data.Currency) {
+		logrus.Error(ErrIncorrectCurrency)
+		return id, ErrIncorrectCurrency
 	}
 
-	newSub := mappers.CreateWebToModel(data)
+	// если дату окончания не дали, подписка бессрочная
+	newSub, err := mappers.CreateWebToModel(data)
+	if err != nil {
+		logrus.Error(err)
+		return id, err
+	}
 
-	if newSub.EndDate.Before(newSub.StartDate) {
+	if newSub.EndDate != nil && newSub.EndDate.Before(newSub.StartDate) {
 		logrus.Error(ErrEndIsLess)
 		return id, ErrEndIsLess
 	}
 
-	id, err := s.storage.Create(ctx, newSub)
+	id, err = s.storage.Create(ctx, newSub)
 	if err != nil {
 		logrus.Error(err)
 		return id, err
@@ -77,69 +105,235 @@ func (s *sub) Load(ctx context.Context, id int) (dto.LoadSubResponce, error) {
 		logrus.Error(err)
 		return res, err
 	}
+	prices, err := s.currentPrices(ctx, []model.Subscription{data})
+	if err != nil {
+		logrus.Error(err)
+		return res, err
+	}
+
 	res = mappers.ModelToLoadWeb(data)
+	price := prices[data.Id]
+	res.CurrentPrice = &price
 	logrus.Info("sub service: load success")
 	return res, nil
 }
 
-func (s *sub) LoadList(ctx context.Context, limit int, offset int) ([]dto.LoadSubResponce, error) {
+func (s *sub) LoadList(ctx context.Context, data dto.LoadListRequest) (dto.LoadListResponce, error) {
 	logrus.Info("sub service: load list")
-	res := []dto.LoadSubResponce{}
-	data, err := s.storage.LoadList(ctx, limit, offset)
+	res := dto.LoadListResponce{
+		Items:  []dto.LoadSubResponce{},
+		Limit:  data.Limit,
+		Offset: data.Offset,
+	}
+
+	if data.Limit < 0 || data.Offset < 0 {
+		logrus.Error(ErrIncorrectValue)
+		return res, ErrIncorrectValue
+	}
+
+	if data.PriceMin != nil && data.PriceMax != nil && data.PriceMax.LessThan(*data.PriceMin) {
+		logrus.Error(ErrPriceRange)
+		return res, ErrPriceRange
+	}
+
+	if !sortFields[data.Sort] || (data.Order != "" && data.Order != "asc" && data.Order != "desc") {
+		logrus.Error(ErrIncorrectSort)
+		return res, ErrIncorrectSort
+	}
+
+	filter, err := mappers.LoadListWebToDB(data)
 	if err != nil {
 		logrus.Error(err)
 		return res, err
 	}
-	for _, sub := range data {
+
+	if data.Cursor != "" {
+		// курсор и смещение вместе не имеют смысла
+		if data.Offset != 0 {
+			logrus.Error(ErrIncorrectCursor)
+			return res, ErrIncorrectCursor
+		}
+		after, err := decodeCursor(data.Cursor, data.Sort, data.Order)
+		if err != nil {
+			logrus.Error(err)
+			return res, err
+		}
+		filter.After = &after
+	}
+
+	total, err := s.storage.Count(ctx, filter)
+	if err != nil {
+		logrus.Error(err)
+		return res, err
+	}
+	res.Total = total
+
+	// берем на одну запись больше, чтобы понять есть ли следующая страница
+	filter.Limit++
+
+	subs, err := s.storage.LoadList(ctx, filter)
+	if err != nil {
+		logrus.Error(err)
+		return res, err
+	}
+
+	if len(subs) > data.Limit {
+		subs = subs[:data.Limit]
+		if len(subs) > 0 {
+			res.NextCursor = encodeCursor(data.Sort, data.Order, subs[len(subs)-1])
+		}
+	}
+
+	prices, err := s.currentPrices(ctx, subs)
+	if err != nil {
+		logrus.Error(err)
+		return res, err
+	}
+
+	for _, sub := range subs {
 		temp := mappers.ModelToLoadWeb(sub)
-		res = append(res, temp)
+		price := prices[sub.Id]
+		temp.CurrentPrice = &price
+		res.Items = append(res.Items, temp)
 	}
 	logrus.Info("sub service: load list success")
 	return res, nil
 }
 
-func (s *sub) Update(ctx context.Context, data dto.UpdateSubRequest) error {
+// Update replaces the subscription if it is still at the given version,
+// a zero version skips the check. It returns the new version.
+func (s *sub) Update(ctx context.Context, id int, version int, data dto.UpdateSubRequest) (int, error) {
 	logrus.Info("sub service: update")
 
-	ok := checkDateStr(data.StartDate)
+	if data.Price == nil {
+		logrus.Error(ErrPriceRequired)
+		return 0, ErrPriceRequired
+	}
 
-	if !ok {
-		logrus.Error(ErrIncorrectDate)
-		return ErrIncorrectDate
+	if !model.IsPrice(*data.Price) {
+		logrus.Error(ErrIncorrectPrice)
+		return 0, ErrIncorrectPrice
 	}
 
-	// если дату окончания не дали, будем считать что дата окончания конец столетия
+	if data.BillingPeriod == "" {
+		data.BillingPeriod = model.PeriodMonthly
+	}
 
-	if data.EndDate == "" {
-		data.EndDate = "12-2099"
+	if !checkBillingPeriod(data.BillingPeriod) {
+		logrus.Error(ErrBillingPeriod)
+		return 0, ErrBillingPeriod
 	}
 
-	ok = checkDateStr(data.EndDate)
+	if data.Currency == "" {
+		data.Currency = model.BaseCurrency
+	}
 
-	if !ok {
-		logrus.Error(ErrIncorrectDate)
-		return ErrIncorrectDate
+	if !model.IsCurrency(data.Currency) {
+		logrus.Error(ErrIncorrectCurrency)
+		return 0, ErrIncorrectCurrency
 	}
 
-	sub := mappers.UpdateWebToModel(data)
+	// если дату окончания не дали, подписка бессрочная
+	sub, err := mappers.UpdateWebToModel(id, data)
+	if err != nil {
+		logrus.Error(err)
+		return 0, err
+	}
+	sub.Version = version
 
-	if sub.EndDate.Before(sub.StartDate) {
+	if sub.EndDate != nil && sub.EndDate.Before(sub.StartDate) {
 		logrus.Error(ErrEndIsLess)
-		return ErrEndIsLess
+		return 0, ErrEndIsLess
 	}
 
-	err := s.storage.Update(ctx, sub)
+	current, err := s.storage.Load(ctx, id)
 	if err != nil {
 		logrus.Error(err)
-		return err
+		return 0, err
+	}
+
+	// запланированные изменения цены перекрыли бы новую цену
+	err = s.checkPriceEdit(ctx, current, sub.Price)
+	if err != nil {
+		logrus.Error(err)
+		return 0, err
+	}
+
+	newVersion, err := s.storage.Update(ctx, sub)
+	if err != nil {
+		logrus.Error(err)
+		return 0, err
 	}
 	logrus.Info("sub service: update success")
-	return nil
+	return newVersion, nil
 }
 
-func (s *sub) Delete(ctx context.Context, id int) error {
+// Patch applies the merge patch if the subscription is still at the given version,
+// a zero version skips the check. It returns the new version.
+func (s *sub) Patch(ctx context.Context, id int, version int, data dto.PatchSubRequest) (int, error) {
+	logrus.Info("sub service: patch")
+
+	if data.Price != nil && !model.IsPrice(*data.Price) {
+		logrus.Error(ErrIncorrectPrice)
+		return 0, ErrIncorrectPrice
+	}
+
+	if data.BillingPeriod != nil && !checkBillingPeriod(*data.BillingPeriod) {
+		logrus.Error(ErrBillingPeriod)
+		return 0, ErrBillingPeriod
+	}
+
+	if data.Currency != nil && !model.IsCurrency(*data.Currency) {
+		logrus.Error(ErrIncorrectCurrency)
+		return 0, ErrIncorrectCurrency
+	}
+
+	current, err := s.storage.Load(ctx, id)
+	if err != nil {
+		logrus.Error(err)
+		return 0, err
+	}
+
+	if version != 0 && current.Version != version {
+		logrus.Error(domain.ErrVersionMismatch)
+		return 0, domain.ErrVersionMismatch
+	}
+
+	if data.Price != nil {
+		err = s.checkPriceEdit(ctx, current, *data.Price)
+		if err != nil {
+			logrus.Error(err)
+			return 0, err
+		}
+	}
+
+	// патч применяется к загруженной версии, поэтому обновление
+	// всегда проверяет, что запись не изменилась после загрузки
+	sub, err := mappers.PatchToModel(current, data)
+	if err != nil {
+		logrus.Error(err)
+		return 0, err
+	}
+
+	if sub.EndDate != nil && sub.EndDate.Before(sub.StartDate) {
+		logrus.Error(ErrEndIsLess)
+		return 0, ErrEndIsLess
+	}
+
+	newVersion, err := s.storage.Update(ctx, sub)
+	if err != nil {
+		logrus.Error(err)
+		return 0, err
+	}
+	logrus.Info("sub service: patch success")
+	return newVersion, nil
+}
+
+// Delete removes the subscription if it is still at the given version,
+// a zero version skips the check.
+func (s *sub) Delete(ctx context.Context, id int, version int) error {
 	logrus.Info("sub service: delete")
-	err := s.storage.Delete(ctx, id)
+	err := s.storage.Delete(ctx, id, version)
 	if err != nil {
 		logrus.Error(err)
 		return err
@@ -148,89 +342,327 @@ func (s *sub) Delete(ctx context.Context, id int) error {
 	return nil
 }
 
+// Restore brings back a soft-deleted subscription and returns its new version.
+func (s *sub) Restore(ctx context.Context, id int) (int, error) {
+	logrus.Info("sub service: restore")
+	version, err := s.storage.Restore(ctx, id)
+	if err != nil {
+		logrus.Error(err)
+		return 0, err
+	}
+	logrus.Info("sub service: restore success")
+	return version, nil
+}
+
+// Purge permanently removes subscriptions deleted longer than retention ago.
+func (s *sub) Purge(ctx context.Context, retention time.Duration) (int, error) {
+	logrus.Info("sub service: purge")
+	count, err := s.storage.Purge(ctx, time.Now().Add(-retention))
+	if err != nil {
+		logrus.Error(err)
+		return 0, err
+	}
+	logrus.Infof("sub service: purge success, removed %d", count)
+	return count, nil
+}
+
+func (s *sub) History(ctx context.Context, id int) ([]dto.HistoryResponce, error) {
+	logrus.Info("sub service: history")
+	res := []dto.HistoryResponce{}
+	entries, err := s.storage.History(ctx, id)
+	if err != nil {
+		logrus.Error(err)
+		return res, err
+	}
+
+	// подписки, созданные до появления истории, не имеют записей
+	if len(entries) == 0 {
+		_, err = s.storage.Load(ctx, id)
+		if err != nil {
+			logrus.Error(err)
+			return res, err
+		}
+	}
+
+	for _, entry := range entries {
+		res = append(res, mappers.HistoryToWeb(entry))
+	}
+	logrus.Info("sub service: history success")
+	return res, nil
+}
+
 func (s *sub) Cost(ctx context.Context, data dto.CostRequest) (dto.CostResponce, error) {
+	logrus.Info("sub service: cost")
 	result := dto.CostResponce{}
 
-	start := mappers.ConvertStringToDate(data.StartDate)
-	end := mappers.ConvertStringToDate(data.EndDate)
+	dbData, err := costFilter(data)
+	if err != nil {
+		logrus.Error(err)
+		return result, err
+	}
 
-	ok := checkDateStr(data.StartDate)
+	if data.TargetCurrency == "" {
+		data.TargetCurrency = model.BaseCurrency
+	}
 
-	if !ok {
-		logrus.Error(ErrIncorrectDate)
-		return result, ErrIncorrectDate
+	if !model.IsCurrency(data.TargetCurrency) {
+		logrus.Error(ErrIncorrectTargetCurrency)
+		return result, ErrIncorrectTargetCurrency
 	}
 
-	ok = checkDateStr(data.EndDate)
+	start, end := dbData.StartDate, dbData.EndDate
 
-	if !ok {
-		logrus.Error(ErrIncorrectDate)
-		return result, ErrIncorrectDate
+	subs, err := s.storage.Cost(ctx, dbData)
+	if err != nil {
+		logrus.Error(err)
+		return result, err
 	}
 
-	// если дата окончания раньше старта, то возвращаем ошибку
-	if end.Before(start) {
-		logrus.Error(ErrEndIsLess)
-		return result, ErrEndIsLess
+	// дата окончания окна включается целиком
+	windowEnd := end.AddDate(0, 0, 1)
+
+	pricing, err := s.loadPricing(ctx, subs, data.TargetCurrency, windowEnd)
+	if err != nil {
+		logrus.Error(err)
+		return result, err
+	}
+
+	result.ServiceName = data.ServiceName
+	if data.UserId != uuid.Nil {
+		result.UserId = &data.UserId
 	}
+	result.Currency = data.TargetCurrency
+	result.Groups = []dto.CostGroup{}
+	result.Subscriptions = make([]dto.CostItem, 0, len(subs))
+
+	for _, sub := range subs {
+		subStart, subEnd := start, end
+
+		// обрезаем период подписки по запрошенному окну
+		if subStart.Before(sub.StartDate) {
+			subStart = sub.StartDate
+		}
+		if sub.EndDate != nil && sub.EndDate.Before(subEnd) {
+			subEnd = *sub.EndDate
+		}
+
+		// +1 потому что учитываем мес включительно
+		monthsCount := monthDiff(subStart, subEnd) + 1
+
+		// стоимость считаем по списаниям, попавшим в окно
+		cost, charges, err := pricing.cost(sub, start, windowEnd, data.Prorate)
+		if err != nil {
+			logrus.Error(err)
+			return dto.CostResponce{}, err
+		}
+
+		result.Subscriptions = append(result.Subscriptions, dto.CostItem{
+			Id:            sub.Id,
+			ServiceName:   sub.ServiceName,
+			UserId:        sub.UserId,
+			BillingPeriod: sub.BillingPeriod,
+			Currency:      sub.Currency,
+			MonthsCount:   monthsCount,
+			ChargesCount:  charges,
+			Cost:          cost,
+		})
+		result.Cost = result.Cost.Add(cost)
+		result.MonthsCount += monthsCount
+		result.ChargesCount += charges
+
+		// подписки приходят отсортированными по сервису и пользователю,
+		// поэтому группа всегда последняя в списке
+		last := len(result.Groups) - 1
+		if last < 0 || result.Groups[last].ServiceName != sub.ServiceName || result.Groups[last].UserId != sub.UserId {
+			result.Groups = append(result.Groups, dto.CostGroup{
+				ServiceName: sub.ServiceName,
+				UserId:      sub.UserId,
+			})
+			last++
+		}
+		result.Groups[last].Cost = result.Groups[last].Cost.Add(cost)
+		result.Groups[last].MonthsCount += monthsCount
+		result.Groups[last].ChargesCount += charges
+	}
+
+	logrus.Info("sub service: cost success")
+	return result, nil
+}
 
-	dbData := mappers.CostRequestToCostDB(data)
+func (s *sub) CostMonthly(ctx context.Context, data dto.CostRequest) (dto.CostMonthlyResponce, error) {
+	logrus.Info("sub service: cost monthly")
+	result := dto.CostMonthlyResponce{}
 
-	sub, err := s.storage.Cost(ctx, dbData)
+	dbData, err := costFilter(data)
 	if err != nil {
 		logrus.Error(err)
 		return result, err
 	}
 
-	// определить начальную дату
-	if start.Before(sub.StartDate) {
-		start = sub.StartDate
+	if data.TargetCurrency == "" {
+		data.TargetCurrency = model.BaseCurrency
 	}
 
-	// определить конечную дату
-	if sub.EndDate.Before(end) {
-		end = sub.EndDate
+	if !model.IsCurrency(data.TargetCurrency) {
+		logrus.Error(ErrIncorrectTargetCurrency)
+		return result, ErrIncorrectTargetCurrency
 	}
 
-	// +1 потому что учитываем мес включительно
-	monthsCount := monthDiff(start, end) + 1
+	subs, err := s.storage.Cost(ctx, dbData)
+	if err != nil {
+		logrus.Error(err)
+		return result, err
+	}
 
-	cost := monthsCount * int(sub.Price)
+	start := dbData.StartDate
+	windowEnd := dbData.EndDate.AddDate(0, 0, 1)
 
-	result.Cost = cost
-	result.MonthsCount = monthsCount
-	result.ServiceName = sub.ServiceName
-	result.UserId = sub.UserId
+	pricing, err := s.loadPricing(ctx, subs, data.TargetCurrency, windowEnd)
+	if err != nil {
+		logrus.Error(err)
+		return result, err
+	}
 
+	result.ServiceName = data.ServiceName
+	if data.UserId != uuid.Nil {
+		result.UserId = &data.UserId
+	}
+	result.Currency = data.TargetCurrency
+	result.Months = []dto.CostMonth{}
+
+	firstMonth := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for month := firstMonth; month.Before(windowEnd); month = month.AddDate(0, 1, 0) {
+		item := dto.CostMonth{
+			Month:         mappers.ConvertDateToString(month),
+			Subscriptions: []dto.CostMonthItem{},
+		}
+
+		// первый и последний месяц могут быть обрезаны окном
+		from, to := month, month.AddDate(0, 1, 0)
+		if from.Before(start) {
+			from = start
+		}
+		if to.After(windowEnd) {
+			to = windowEnd
+		}
+
+		for _, sub := range subs {
+			cost, charges, err := pricing.cost(sub, from, to, data.Prorate)
+			if err != nil {
+				logrus.Error(err)
+				return dto.CostMonthlyResponce{}, err
+			}
+			if charges == 0 {
+				continue
+			}
+			item.Subscriptions = append(item.Subscriptions, dto.CostMonthItem{
+				Id:            sub.Id,
+				ServiceName:   sub.ServiceName,
+				UserId:        sub.UserId,
+				BillingPeriod: sub.BillingPeriod,
+				Currency:      sub.Currency,
+				ChargesCount:  charges,
+				Cost:          cost,
+			})
+			item.Cost = item.Cost.Add(cost)
+		}
+		result.Cost = result.Cost.Add(item.Cost)
+		result.Months = append(result.Months, item)
+	}
+
+	logrus.Info("sub service: cost monthly success")
 	return result, nil
 }
 
-func monthDiff(start, end time.Time) int {
-	startYear, startMonth, _ := start.Date()
-	endYear, endMonth, _ := end.Date()
+// SetPrice schedules a subscription price change from the given month on
+// if the subscription is still at the version, it returns the new version.
+func (s *sub) SetPrice(ctx context.Context, id int, version int, data dto.PriceChangeRequest) (int, error) {
+	logrus.Info("sub service: set price")
 
-	totalStart := startYear*12 + int(startMonth)
-	totalEnd := endYear*12 + int(endMonth)
+	if data.Price == nil {
+		logrus.Error(ErrPriceRequired)
+		return 0, ErrPriceRequired
+	}
 
-	return totalEnd - totalStart
+	if !model.IsPrice(*data.Price) {
+		logrus.Error(ErrIncorrectPrice)
+		return 0, ErrIncorrectPrice
+	}
+
+	current, err := s.storage.Load(ctx, id)
+	if err != nil {
+		logrus.Error(err)
+		return 0, err
+	}
+
+	change, err := mappers.PriceChangeWebToModel(id, data)
+	if err != nil {
+		logrus.Error(err)
+		return 0, err
+	}
+
+	if change.EffectiveFrom.Before(current.StartDate) || (current.EndDate != nil && current.EndDate.Before(change.EffectiveFrom)) {
+		logrus.Error(ErrOutOfPeriod)
+		return 0, ErrOutOfPeriod
+	}
+
+	version, err = s.storage.SetPrice(ctx, change, version)
+	if err != nil {
+		logrus.Error(err)
+		return 0, err
+	}
+	logrus.Info("sub service: set price success")
+	return version, nil
 }
 
-func checkDateStr(date string) bool {
-	var month, year int
-	n, err := fmt.Sscanf(date, "%d-%d", &month, &year)
-	if err != nil || n != 2 {
-		return false
+func (s *sub) Prices(ctx context.Context, id int) ([]dto.PriceChangeResponce, error) {
+	logrus.Info("sub service: prices")
+	res := []dto.PriceChangeResponce{}
+
+	_, err := s.storage.Load(ctx, id)
+	if err != nil {
+		logrus.Error(err)
+		return res, err
 	}
 
-	// Захардкодил проверку на месяц и год, минимальный допустимый год 1999, максимальный 2099
+	changes, err := s.storage.Prices(ctx, []int{id})
+	if err != nil {
+		logrus.Error(err)
+		return res, err
+	}
+
+	for _, change := range changes {
+		res = append(res, mappers.PriceChangeToWeb(change))
+	}
+	logrus.Info("sub service: prices success")
+	return res, nil
+}
 
-	if month > 12 || month <= 0 {
-		return false
+// costFilter validates the period of a cost request and converts it to the storage filter.
+func costFilter(data dto.CostRequest) (dto.CostRequestToDB, error) {
+	res, err := mappers.CostRequestToCostDB(data)
+	if err != nil {
+		return res, err
 	}
 
-	if year < 1999 || year > 2099 {
-		return false
+	// если дата окончания раньше старта, то возвращаем ошибку
+	if res.EndDate.Before(res.StartDate) {
+		return res, ErrEndIsLess
 	}
+	return res, nil
+}
+
+func monthDiff(start, end time.Time) int {
+	startYear, startMonth, _ := start.Date()
+	endYear, endMonth, _ := end.Date()
+
+	totalStart := startYear*12 + int(startMonth)
+	totalEnd := endYear*12 + int(endMonth)
+
+	return totalEnd - totalStart
+}
 
-	return true
+func checkBillingPeriod(period string) bool {
+	_, ok := billingPeriods[period]
+	return ok
 }
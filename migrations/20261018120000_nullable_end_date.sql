@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- open-ended subscriptions used to be stored with 12-2099 as end date
+UPDATE subscriptions SET end_date = NULL WHERE end_date = '2099-12-01';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE subscriptions SET end_date = '2099-12-01' WHERE end_date IS NULL
+
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- prices are amounts with kopecks (cents) in the subscription currency
+ALTER TABLE subscriptions ALTER COLUMN price TYPE NUMERIC(14, 2);
+
+ALTER TABLE subscriptions ADD CONSTRAINT subscriptions_price_check CHECK (price >= 0);
+
+ALTER TABLE subscription_prices ALTER COLUMN price TYPE NUMERIC(14, 2);
+
+ALTER TABLE subscription_prices ADD CONSTRAINT subscription_prices_price_check CHECK (price >= 0);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE subscription_prices DROP CONSTRAINT IF EXISTS subscription_prices_price_check;
+
+ALTER TABLE subscription_prices ALTER COLUMN price TYPE INTEGER USING round(price);
+
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_price_check;
+
+ALTER TABLE subscriptions ALTER COLUMN price TYPE INTEGER USING round(price)
+
+-- +goose StatementEnd
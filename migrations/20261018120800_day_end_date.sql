@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- end dates used to be the first day of the last month, now they are inclusive days
+UPDATE subscriptions SET end_date = (end_date + INTERVAL '1 month' - INTERVAL '1 day')::date
+WHERE end_date IS NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE subscriptions SET end_date = date_trunc('month', end_date)::date
+WHERE end_date IS NOT NULL
+
+-- +goose StatementEnd
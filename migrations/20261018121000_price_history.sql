@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- price changes are recorded in the history with the scheduled price
+ALTER TABLE subscription_history ADD COLUMN price_change JSONB;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE subscription_history DROP COLUMN IF EXISTS price_change
+
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+// Package migrations embeds the goose SQL migrations so the service binary
+// can apply them itself.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// Postgres holds the PostgreSQL migrations.
+//
+//go:embed *.sql
+var Postgres embed.FS
+
+//go:embed sqlite/*.sql
+var sqliteFiles embed.FS
+
+// SQLite holds the SQLite migrations from the sqlite directory.
+var SQLite, _ = fs.Sub(sqliteFiles, "sqlite")
@@ -0,0 +1,67 @@
+-- +goose Up
+-- +goose StatementBegin
+-- даты хранятся как YYYY-MM-DD, моменты времени как YYYY-MM-DD HH:MM:SS.ffffff в UTC,
+-- цены в копейках, курсы десятичной строкой
+CREATE TABLE subscriptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    service_name TEXT NOT NULL,
+    price INTEGER NOT NULL CHECK (price >= 0),
+    user_id TEXT NOT NULL,
+    start_date TEXT NOT NULL,
+    end_date TEXT,
+    billing_period TEXT NOT NULL DEFAULT 'monthly'
+        CHECK (billing_period IN ('weekly', 'monthly', 'quarterly', 'annual')),
+    currency TEXT NOT NULL DEFAULT 'RUB' CHECK (currency GLOB '[A-Z][A-Z][A-Z]'),
+    version INTEGER NOT NULL DEFAULT 1,
+    deleted_at TEXT
+);
+
+CREATE INDEX idx_service_name ON subscriptions(service_name);
+
+CREATE INDEX idx_subscription_dates ON subscriptions(start_date, end_date);
+
+CREATE INDEX idx_user_id ON subscriptions(user_id);
+
+CREATE INDEX idx_deleted_at ON subscriptions(deleted_at) WHERE deleted_at IS NOT NULL;
+
+CREATE TABLE subscription_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    subscription_id INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    changed_at TEXT NOT NULL,
+    before TEXT,
+    after TEXT
+);
+
+CREATE INDEX idx_history_subscription_id ON subscription_history(subscription_id, changed_at);
+
+CREATE TABLE subscription_prices (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    subscription_id INTEGER NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    effective_from TEXT NOT NULL,
+    price INTEGER NOT NULL CHECK (price >= 0),
+    UNIQUE (subscription_id, effective_from)
+);
+
+-- rate is the amount of rubles for one unit of the currency in the month
+CREATE TABLE exchange_rates (
+    currency TEXT NOT NULL CHECK (currency GLOB '[A-Z][A-Z][A-Z]'),
+    month TEXT NOT NULL,
+    rate TEXT NOT NULL,
+    PRIMARY KEY (currency, month)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS exchange_rates;
+
+DROP TABLE IF EXISTS subscription_prices;
+
+DROP TABLE IF EXISTS subscription_history;
+
+DROP TABLE IF EXISTS subscriptions
+
+-- +goose StatementEnd
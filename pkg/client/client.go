@@ -0,0 +1,202 @@
+// Package client is a Go client of the subscription service REST API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultAttempts = 3
+	defaultDelay    = 200 * time.Millisecond
+)
+
+// Client calls the subscription service. It is safe for concurrent use.
+type Client struct {
+	server   string
+	actor    string
+	token    string
+	http     *http.Client
+	attempts int
+	delay    time.Duration
+}
+
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client, http.DefaultClient by default.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.http = httpClient
+	}
+}
+
+// WithActor sets the name recorded in the history of changes.
+func WithActor(actor string) Option {
+	return func(c *Client) {
+		c.actor = actor
+	}
+}
+
+// WithToken sets the bearer token sent in the Authorization header.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithRetries sets how many times a request is tried and the delay before
+// the second try, the delay doubles after every try. One attempt turns retries off.
+func WithRetries(attempts int, delay time.Duration) Option {
+	return func(c *Client) {
+		c.attempts = max(attempts, 1)
+		c.delay = delay
+	}
+}
+
+// New creates a client of the service at the server URL, e.g. http://127.0.0.1:8888.
+func New(server string, opts ...Option) *Client {
+	c := &Client{
+		server:   strings.TrimRight(server, "/"),
+		http:     http.DefaultClient,
+		attempts: defaultAttempts,
+		delay:    defaultDelay,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// request is one API call. Retry marks calls that are safe to send twice,
+// IfMatch is sent when it is not empty.
+type request struct {
+	method  string
+	path    string
+	query   url.Values
+	body    any
+	ifMatch string
+	retry   bool
+}
+
+// do sends the request and decodes the response body into out, it returns
+// the response headers. Failed idempotent requests are retried on network
+// errors and on responses of an overloaded or restarting server.
+func (c *Client) do(ctx context.Context, req request, out any) (http.Header, error) {
+	var body []byte
+	if req.body != nil {
+		var err error
+		body, err = json.Marshal(req.body)
+		if err != nil {
+			return nil, fmt.Errorf("encode request err: %w", err)
+		}
+	}
+
+	delay := c.delay
+	for attempt := 1; ; attempt++ {
+		header, err := c.send(ctx, req, body, out)
+		if attempt >= c.attempts || !req.retry || !temporary(ctx, err) {
+			return header, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return header, err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func (c *Client) send(ctx context.Context, req request, body []byte, out any) (http.Header, error) {
+	target := c.server + req.path
+	if len(req.query) != 0 {
+		target += "?" + req.query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if c.actor != "" {
+		httpReq.Header.Set("X-Actor", c.actor)
+	}
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if req.ifMatch != "" {
+		httpReq.Header.Set("If-Match", req.ifMatch)
+	}
+
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := &Error{Status: resp.StatusCode, Title: http.StatusText(resp.StatusCode)}
+		// тело может быть не problem+json, например от прокси
+		_ = json.NewDecoder(resp.Body).Decode(apiErr)
+		apiErr.Status = resp.StatusCode
+		return resp.Header, apiErr
+	}
+
+	if out != nil {
+		err = json.NewDecoder(resp.Body).Decode(out)
+		if err != nil {
+			return resp.Header, fmt.Errorf("decode response err: %w", err)
+		}
+	}
+	return resp.Header, nil
+}
+
+// temporary reports whether the request may succeed when sent again.
+func temporary(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.Status {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	// ответа не было: сеть или сервер перезапускается
+	return true
+}
+
+// ifMatch returns the If-Match value of the version, zero skips the version check.
+func ifMatch(version int) string {
+	if version == 0 {
+		return "*"
+	}
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// etagVersion returns the subscription version from the ETag header.
+func etagVersion(header http.Header) (int, error) {
+	s, err := strconv.Unquote(strings.TrimPrefix(header.Get("ETag"), "W/"))
+	if err != nil {
+		return 0, fmt.Errorf("incorrect ETag %q", header.Get("ETag"))
+	}
+	return strconv.Atoi(s)
+}
@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// Create creates a subscription and returns its id.
+func (c *Client) Create(ctx context.Context, data CreateSubRequest) (int, error) {
+	var resp struct {
+		SubscriptionId int `json:"subscription_id"`
+	}
+	// повтор создания после обрыва может создать вторую подписку
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/subscription", body: data}, &resp)
+	if err != nil {
+		return 0, err
+	}
+	return resp.SubscriptionId, nil
+}
+
+func (c *Client) Load(ctx context.Context, id int) (LoadSubResponce, error) {
+	var resp LoadSubResponce
+	_, err := c.do(ctx, request{method: http.MethodGet, path: subscriptionPath(id), retry: true}, &resp)
+	return resp, err
+}
+
+// LoadList returns a page of subscriptions, pass NextCursor of the page
+// as Cursor to load the next one.
+func (c *Client) LoadList(ctx context.Context, data LoadListRequest) (LoadListResponce, error) {
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(data.Limit))
+	if data.Offset != 0 {
+		query.Set("offset", strconv.Itoa(data.Offset))
+	}
+	if data.UserId != uuid.Nil {
+		query.Set("user_id", data.UserId.String())
+	}
+	if data.PriceMin != nil {
+		query.Set("price_min", data.PriceMin.String())
+	}
+	if data.PriceMax != nil {
+		query.Set("price_max", data.PriceMax.String())
+	}
+	for name, value := range map[string]string{
+		"service_name":   data.ServiceName,
+		"service_prefix": data.ServicePrefix,
+		"active_month":   data.ActiveMonth,
+		"sort":           data.Sort,
+		"order":          data.Order,
+		"cursor":         data.Cursor,
+	} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+
+	var resp LoadListResponce
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/subscription", query: query, retry: true}, &resp)
+	return resp, err
+}
+
+// Update replaces the subscription of the version and returns the new
+// version, version 0 skips the version check.
+func (c *Client) Update(ctx context.Context, id int, version int, data UpdateSubRequest) (int, error) {
+	header, err := c.do(ctx, request{
+		method:  http.MethodPut,
+		path:    subscriptionPath(id),
+		body:    data,
+		ifMatch: ifMatch(version),
+		retry:   version != 0,
+	}, nil)
+	if err != nil {
+		return 0, err
+	}
+	return etagVersion(header)
+}
+
+// Patch changes the set fields of the subscription of the version and
+// returns the new version, version 0 skips the version check.
+func (c *Client) Patch(ctx context.Context, id int, version int, data PatchSubRequest) (int, error) {
+	header, err := c.do(ctx, request{
+		method:  http.MethodPatch,
+		path:    subscriptionPath(id),
+		body:    data,
+		ifMatch: ifMatch(version),
+		retry:   version != 0,
+	}, nil)
+	if err != nil {
+		return 0, err
+	}
+	return etagVersion(header)
+}
+
+// Delete deletes the subscription of the version, version 0 skips the version check.
+func (c *Client) Delete(ctx context.Context, id int, version int) error {
+	_, err := c.do(ctx, request{
+		method:  http.MethodDelete,
+		path:    subscriptionPath(id),
+		ifMatch: ifMatch(version),
+		retry:   version != 0,
+	}, nil)
+	return err
+}
+
+func (c *Client) History(ctx context.Context, id int) ([]HistoryResponce, error) {
+	var resp []HistoryResponce
+	_, err := c.do(ctx, request{method: http.MethodGet, path: subscriptionPath(id) + "/history", retry: true}, &resp)
+	return resp, err
+}
+
+// Restore brings back a deleted subscription and returns its new version.
+func (c *Client) Restore(ctx context.Context, id int) (int, error) {
+	header, err := c.do(ctx, request{method: http.MethodPost, path: subscriptionPath(id) + "/restore"}, nil)
+	if err != nil {
+		return 0, err
+	}
+	return etagVersion(header)
+}
+
+// SetPrice schedules a price change of the subscription of the version and
+// returns the new version, version 0 skips the version check.
+func (c *Client) SetPrice(ctx context.Context, id int, version int, data PriceChangeRequest) (int, error) {
+	header, err := c.do(ctx, request{
+		method:  http.MethodPost,
+		path:    subscriptionPath(id) + "/price",
+		body:    data,
+		ifMatch: ifMatch(version),
+		retry:   version != 0,
+	}, nil)
+	if err != nil {
+		return 0, err
+	}
+	return etagVersion(header)
+}
+
+func (c *Client) Prices(ctx context.Context, id int) ([]PriceChangeResponce, error) {
+	var resp []PriceChangeResponce
+	_, err := c.do(ctx, request{method: http.MethodGet, path: subscriptionPath(id) + "/price", retry: true}, &resp)
+	return resp, err
+}
+
+func (c *Client) Cost(ctx context.Context, data CostRequest) (CostResponce, error) {
+	var resp CostResponce
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/subscription/cost", body: data, retry: true}, &resp)
+	return resp, err
+}
+
+func (c *Client) CostMonthly(ctx context.Context, data CostRequest) (CostMonthlyResponce, error) {
+	var resp CostMonthlyResponce
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/subscription/cost/monthly", body: data, retry: true}, &resp)
+	return resp, err
+}
+
+func subscriptionPath(id int) string {
+	return "/subscription/" + strconv.Itoa(id)
+}
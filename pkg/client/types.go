@@ -0,0 +1,200 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Request and response bodies of the API. Dates are sent as DD-MM-YYYY,
+// YYYY-MM-DD or whole MM-YYYY, YYYY-MM months. Start and end dates are returned
+// as MM-YYYY when they cover whole months and as DD-MM-YYYY otherwise,
+// prices and costs are decimal strings.
+
+type CreateSubRequest struct {
+	ServiceName   string          `json:"service_name"`
+	Price         decimal.Decimal `json:"price"`
+	UserId        uuid.UUID       `json:"user_id"`
+	StartDate     string          `json:"start_date"`
+	EndDate       string          `json:"end_date,omitempty"`
+	BillingPeriod string          `json:"billing_period,omitempty"`
+	Currency      string          `json:"currency,omitempty"`
+}
+
+type UpdateSubRequest struct {
+	ServiceName   string          `json:"service_name"`
+	Price         decimal.Decimal `json:"price"`
+	UserId        uuid.UUID       `json:"user_id"`
+	StartDate     string          `json:"start_date"`
+	EndDate       string          `json:"end_date,omitempty"`
+	BillingPeriod string          `json:"billing_period,omitempty"`
+	Currency      string          `json:"currency,omitempty"`
+}
+
+// PatchSubRequest changes only the set fields of a subscription,
+// a null EndDate makes the subscription open-ended.
+type PatchSubRequest struct {
+	ServiceName   *string          `json:"service_name,omitempty"`
+	Price         *decimal.Decimal `json:"price,omitempty"`
+	UserId        *uuid.UUID       `json:"user_id,omitempty"`
+	StartDate     *string          `json:"start_date,omitempty"`
+	EndDate       NullableString   `json:"end_date,omitzero"`
+	BillingPeriod *string          `json:"billing_period,omitempty"`
+	Currency      *string          `json:"currency,omitempty"`
+}
+
+// NullableString is a patch field that is either left out (Set is false),
+// sent as null or sent as Value.
+type NullableString struct {
+	Set   bool
+	Null  bool
+	Value string
+}
+
+func (n NullableString) MarshalJSON() ([]byte, error) {
+	if n.Null {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.Value)
+}
+
+func (n *NullableString) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	if string(data) == "null" {
+		n.Null = true
+		return nil
+	}
+	return json.Unmarshal(data, &n.Value)
+}
+
+// IsZero reports a field that is not set, omitzero leaves it out.
+func (n NullableString) IsZero() bool {
+	return !n.Set
+}
+
+// LoadListRequest filters and sorts the list, zero values mean the filter
+// is not applied.
+type LoadListRequest struct {
+	Limit         int
+	Offset        int
+	UserId        uuid.UUID
+	ServiceName   string
+	ServicePrefix string
+	PriceMin      *decimal.Decimal
+	PriceMax      *decimal.Decimal
+	ActiveMonth   string
+	Sort          string
+	Order         string
+	Cursor        string
+}
+
+type LoadListResponce struct {
+	Items      []LoadSubResponce `json:"items"`
+	Total      int               `json:"total"`
+	Limit      int               `json:"limit"`
+	Offset     int               `json:"offset"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+// LoadSubResponce is a subscription, Price is its price before the scheduled
+// price changes and CurrentPrice the price in effect today.
+type LoadSubResponce struct {
+	Id            int              `json:"id"`
+	ServiceName   string           `json:"service_name"`
+	Price         decimal.Decimal  `json:"price"`
+	CurrentPrice  *decimal.Decimal `json:"current_price,omitempty"`
+	UserId        uuid.UUID        `json:"user_id"`
+	StartDate     string           `json:"start_date"`
+	EndDate       string           `json:"end_date,omitempty"`
+	BillingPeriod string           `json:"billing_period,omitempty"`
+	Currency      string           `json:"currency,omitempty"`
+	Version       int              `json:"version"`
+}
+
+type HistoryResponce struct {
+	Id          int64                `json:"id"`
+	Action      string               `json:"action"`
+	Actor       string               `json:"actor"`
+	ChangedAt   time.Time            `json:"changed_at"`
+	Before      *LoadSubResponce     `json:"before"`
+	After       *LoadSubResponce     `json:"after"`
+	PriceChange *PriceChangeResponce `json:"price_change,omitempty"`
+}
+
+type PriceChangeRequest struct {
+	Price         decimal.Decimal `json:"price"`
+	EffectiveFrom string          `json:"effective_from"`
+}
+
+type PriceChangeResponce struct {
+	Price         decimal.Decimal `json:"price"`
+	EffectiveFrom string          `json:"effective_from"`
+}
+
+// CostRequest filters the cost query, the cost is converted to
+// TargetCurrency (RUB by default).
+type CostRequest struct {
+	ServiceName    string    `json:"service_name,omitempty"`
+	UserId         uuid.UUID `json:"user_id,omitzero"`
+	StartDate      string    `json:"start_date"`
+	EndDate        string    `json:"end_date"`
+	TargetCurrency string    `json:"target_currency,omitempty"`
+	Prorate        bool      `json:"prorate,omitempty"`
+}
+
+type CostResponce struct {
+	ServiceName   string          `json:"service_name,omitempty"`
+	UserId        *uuid.UUID      `json:"user_id,omitempty"`
+	Currency      string          `json:"currency"`
+	Cost          decimal.Decimal `json:"cost"`
+	MonthsCount   int             `json:"months_count"`
+	ChargesCount  int             `json:"charges_count"`
+	Groups        []CostGroup     `json:"groups"`
+	Subscriptions []CostItem      `json:"subscriptions"`
+}
+
+// CostGroup is a subtotal for one service name and user pair.
+type CostGroup struct {
+	ServiceName  string          `json:"service_name"`
+	UserId       uuid.UUID       `json:"user_id"`
+	Cost         decimal.Decimal `json:"cost"`
+	MonthsCount  int             `json:"months_count"`
+	ChargesCount int             `json:"charges_count"`
+}
+
+type CostItem struct {
+	Id            int             `json:"id"`
+	ServiceName   string          `json:"service_name"`
+	UserId        uuid.UUID       `json:"user_id"`
+	BillingPeriod string          `json:"billing_period"`
+	Currency      string          `json:"currency"`
+	MonthsCount   int             `json:"months_count"`
+	ChargesCount  int             `json:"charges_count"`
+	Cost          decimal.Decimal `json:"cost"`
+}
+
+type CostMonthlyResponce struct {
+	ServiceName string          `json:"service_name,omitempty"`
+	UserId      *uuid.UUID      `json:"user_id,omitempty"`
+	Currency    string          `json:"currency"`
+	Cost        decimal.Decimal `json:"cost"`
+	Months      []CostMonth     `json:"months"`
+}
+
+type CostMonth struct {
+	Month         string          `json:"month"`
+	Cost          decimal.Decimal `json:"cost"`
+	Subscriptions []CostMonthItem `json:"subscriptions"`
+}
+
+type CostMonthItem struct {
+	Id            int             `json:"id"`
+	ServiceName   string          `json:"service_name"`
+	UserId        uuid.UUID       `json:"user_id"`
+	BillingPeriod string          `json:"billing_period"`
+	Currency      string          `json:"currency"`
+	ChargesCount  int             `json:"charges_count"`
+	Cost          decimal.Decimal `json:"cost"`
+}